@@ -189,6 +189,7 @@ func setObjectHeaders(w http.ResponseWriter, objInfo ObjectInfo, rs *HTTPRangeSp
 			if ruleID, expiryTime := lc.PredictExpiryTime(lifecycle.ObjectOpts{
 				Name:             objInfo.Name,
 				UserTags:         objInfo.UserTags,
+				Size:             objInfo.Size,
 				VersionID:        objInfo.VersionID,
 				ModTime:          objInfo.ModTime,
 				IsLatest:         objInfo.IsLatest,
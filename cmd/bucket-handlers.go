@@ -451,6 +451,8 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 		hasLifecycleConfig = true
 	}
 	dErrs := make([]DeleteError, len(deleteObjects.Objects))
+	// transitioned objects to be removed from their tier once deleted.
+	transitioned := make([]lifecycle.ObjectOpts, len(deleteObjects.Objects))
 	for index, object := range deleteObjects.Objects {
 		if apiErrCode := checkRequestAuthType(ctx, r, policy.DeleteObjectAction, bucket, object.ObjectName); apiErrCode != ErrNone {
 			if apiErrCode == ErrSignatureDoesNotMatch || apiErrCode == ErrInvalidAccessKeyID {
@@ -487,6 +489,13 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 		}
 		if hasLifecycleConfig && gerr == nil {
 			object.PurgeTransitioned = goi.TransitionStatus
+			transitioned[index] = lifecycle.ObjectOpts{
+				Name:         goi.Name,
+				UserTags:     goi.UserTags,
+				Size:         goi.Size,
+				VersionID:    goi.VersionID,
+				DeleteMarker: goi.DeleteMarker,
+			}
 		}
 		if replicateDeletes {
 			delMarker, replicate, repsync := checkReplicateDelete(ctx, bucket, ObjectToDelete{
@@ -587,7 +596,7 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 
 	// Write success response.
 	writeSuccessResponseXML(w, encodedSuccessResponse)
-	for _, dobj := range deletedObjects {
+	for i, dobj := range deletedObjects {
 		if dobj.ObjectName == "" {
 			continue
 		}
@@ -603,11 +612,7 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 		}
 
 		if hasLifecycleConfig && dobj.PurgeTransitioned == lifecycle.TransitionComplete { // clean up transitioned tier
-			deleteTransitionedObject(ctx, objectAPI, bucket, dobj.ObjectName, lifecycle.ObjectOpts{
-				Name:         dobj.ObjectName,
-				VersionID:    dobj.VersionID,
-				DeleteMarker: dobj.DeleteMarker,
-			}, false, true)
+			deleteTransitionedObject(ctx, objectAPI, bucket, dobj.ObjectName, transitioned[i], false, true)
 		}
 
 		eventName := event.ObjectRemovedDelete
@@ -171,8 +171,11 @@ func initBackgroundTransition(ctx context.Context, objectAPI ObjectLayer) {
 
 func validateLifecycleTransition(ctx context.Context, bucket string, lfc *lifecycle.Lifecycle) error {
 	for _, rule := range lfc.Rules {
-		if rule.Transition.StorageClass != "" {
-			sameTarget, destbucket, err := validateTransitionDestination(ctx, bucket, rule.Transition.StorageClass)
+		for _, transition := range rule.Transitions {
+			if transition.StorageClass == "" {
+				continue
+			}
+			sameTarget, destbucket, err := validateTransitionDestination(ctx, bucket, transition.StorageClass)
 			if err != nil {
 				return err
 			}
@@ -219,8 +222,10 @@ func transitionSC(ctx context.Context, bucket string) string {
 		if rule.Status == Disabled {
 			continue
 		}
-		if rule.Transition.StorageClass != "" {
-			return rule.Transition.StorageClass
+		for _, transition := range rule.Transitions {
+			if transition.StorageClass != "" {
+				return transition.StorageClass
+			}
 		}
 	}
 	return ""
@@ -237,8 +242,12 @@ func transitionSCInUse(ctx context.Context, lfc *lifecycle.Lifecycle, bucket, ar
 		if rule.Status == Disabled {
 			continue
 		}
-		if rule.Transition.StorageClass != "" && rule.Transition.StorageClass == tgtLabel {
-			return true
+		for _, transition := range rule.Transitions {
+			// Storage classes may be upper-cased on unmarshal,
+			// target labels are matched case-insensitively.
+			if transition.StorageClass != "" && strings.EqualFold(transition.StorageClass, tgtLabel) {
+				return true
+			}
 		}
 	}
 	return false
@@ -370,6 +379,7 @@ func transitionObject(ctx context.Context, objectAPI ObjectLayer, objInfo Object
 	lcOpts := lifecycle.ObjectOpts{
 		Name:     objInfo.Name,
 		UserTags: objInfo.UserTags,
+		Size:     objInfo.Size,
 	}
 	arn := getLifecycleTransitionTargetArn(ctx, lc, objInfo.Bucket, lcOpts)
 	if arn == nil {
@@ -430,8 +440,10 @@ func transitionObject(ctx context.Context, objectAPI ObjectLayer, objInfo Object
 // getLifecycleTransitionTargetArn returns transition ARN for storage class specified in the config.
 func getLifecycleTransitionTargetArn(ctx context.Context, lc *lifecycle.Lifecycle, bucket string, obj lifecycle.ObjectOpts) *madmin.ARN {
 	for _, rule := range lc.FilterActionableRules(obj) {
-		if rule.Transition.StorageClass != "" {
-			return globalBucketTargetSys.GetRemoteArnWithLabel(ctx, bucket, rule.Transition.StorageClass)
+		for _, transition := range rule.Transitions {
+			if transition.StorageClass != "" {
+				return globalBucketTargetSys.GetRemoteArnWithLabel(ctx, bucket, transition.StorageClass)
+			}
 		}
 	}
 	return nil
@@ -448,6 +460,7 @@ func getTransitionedObjectReader(ctx context.Context, bucket, object string, rs
 	arn := getLifecycleTransitionTargetArn(ctx, lc, bucket, lifecycle.ObjectOpts{
 		Name:         object,
 		UserTags:     oi.UserTags,
+		Size:         oi.Size,
 		ModTime:      oi.ModTime,
 		VersionID:    oi.VersionID,
 		DeleteMarker: oi.DeleteMarker,
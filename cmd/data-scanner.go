@@ -310,7 +310,7 @@ func scanDataFolder(ctx context.Context, basePath string, cache dataUsageCache,
 		}
 		if s.withFilter != nil {
 			_, prefix := path2BucketObjectWithBasePath(basePath, folder.name)
-			if s.oldCache.Info.lifeCycle == nil || !s.oldCache.Info.lifeCycle.HasActiveRules(prefix, true) {
+			if s.oldCache.Info.lifeCycle == nil || !s.oldCache.Info.lifeCycle.HasActiveObjectRules(prefix, true) {
 				// If folder isn't in filter, skip it completely.
 				if !s.withFilter.containsDir(folder.name) {
 					if !h.mod(s.oldCache.Info.NextCycle, s.healFolderInclude/folder.objectHealProbDiv) {
@@ -371,7 +371,7 @@ func (f *folderScanner) scanQueuedLevels(ctx context.Context, folders []cachedFo
 		filter := f.withFilter
 		_, prefix := path2BucketObjectWithBasePath(f.root, folder.name)
 		var activeLifeCycle *lifecycle.Lifecycle
-		if f.oldCache.Info.lifeCycle != nil && f.oldCache.Info.lifeCycle.HasActiveRules(prefix, true) {
+		if f.oldCache.Info.lifeCycle != nil && f.oldCache.Info.lifeCycle.HasActiveObjectRules(prefix, true) {
 			if f.dataUsageScannerDebug {
 				console.Debugf(scannerLogPrefix+" Prefix %q has active rules\n", prefix)
 			}
@@ -706,7 +706,7 @@ func (f *folderScanner) deepScanFolder(ctx context.Context, folder cachedFolder,
 
 		bucket, prefix := path2BucketObjectWithBasePath(f.root, fileName)
 		var activeLifeCycle *lifecycle.Lifecycle
-		if f.oldCache.Info.lifeCycle != nil && f.oldCache.Info.lifeCycle.HasActiveRules(prefix, false) {
+		if f.oldCache.Info.lifeCycle != nil && f.oldCache.Info.lifeCycle.HasActiveObjectRules(prefix, false) {
 			if f.dataUsageScannerDebug {
 				console.Debugf(deepScannerLogPrefix+" Prefix %q has active rules\n", prefix)
 			}
@@ -793,6 +793,9 @@ func (i *scannerItem) transformMetaDir() {
 type actionMeta struct {
 	oi         ObjectInfo
 	bitRotScan bool // indicates if bitrot check was requested.
+	// noncurrentNum numbers noncurrent versions from the most recent
+	// one, 1, 0 if not known, see lifecycle.ObjectOpts.
+	noncurrentNum int
 }
 
 var applyActionsLogPrefix = color.Green("applyActions:")
@@ -836,19 +839,23 @@ func (i *scannerItem) applyActions(ctx context.Context, o ObjectLayer, meta acti
 	}
 
 	versionID := meta.oi.VersionID
-	action := i.lifeCycle.ComputeAction(
+	// The object is evaluated again by evalActionFromLifecycle
+	// before acting on it, don't report this rule match.
+	action := i.lifeCycle.PeekAction(
 		lifecycle.ObjectOpts{
-			Name:             i.objectPath(),
-			UserTags:         meta.oi.UserTags,
-			ModTime:          meta.oi.ModTime,
-			VersionID:        meta.oi.VersionID,
-			DeleteMarker:     meta.oi.DeleteMarker,
-			IsLatest:         meta.oi.IsLatest,
-			NumVersions:      meta.oi.NumVersions,
-			SuccessorModTime: meta.oi.SuccessorModTime,
-			RestoreOngoing:   meta.oi.RestoreOngoing,
-			RestoreExpires:   meta.oi.RestoreExpires,
-			TransitionStatus: meta.oi.TransitionStatus,
+			Name:                    i.objectPath(),
+			UserTags:                meta.oi.UserTags,
+			Size:                    meta.oi.Size,
+			ModTime:                 meta.oi.ModTime,
+			VersionID:               meta.oi.VersionID,
+			DeleteMarker:            meta.oi.DeleteMarker,
+			IsLatest:                meta.oi.IsLatest,
+			NumVersions:             meta.oi.NumVersions,
+			SuccessorModTime:        meta.oi.SuccessorModTime,
+			RestoreOngoing:          meta.oi.RestoreOngoing,
+			RestoreExpires:          meta.oi.RestoreExpires,
+			TransitionStatus:        meta.oi.TransitionStatus,
+			NoncurrentVersionNumber: meta.noncurrentNum,
 		})
 	if i.debug {
 		if versionID != "" {
@@ -891,7 +898,7 @@ func (i *scannerItem) applyActions(ctx context.Context, o ObjectLayer, meta acti
 	}
 
 	var applied bool
-	action = evalActionFromLifecycle(ctx, *i.lifeCycle, obj, i.debug)
+	action = evalActionFromLifecycle(ctx, *i.lifeCycle, obj, meta.noncurrentNum, i.debug)
 	if action != lifecycle.NoneAction {
 		applied = applyLifecycleAction(ctx, action, o, obj)
 	}
@@ -906,19 +913,21 @@ func (i *scannerItem) applyActions(ctx context.Context, o ObjectLayer, meta acti
 	return size
 }
 
-func evalActionFromLifecycle(ctx context.Context, lc lifecycle.Lifecycle, obj ObjectInfo, debug bool) (action lifecycle.Action) {
+func evalActionFromLifecycle(ctx context.Context, lc lifecycle.Lifecycle, obj ObjectInfo, noncurrentNum int, debug bool) (action lifecycle.Action) {
 	lcOpts := lifecycle.ObjectOpts{
-		Name:             obj.Name,
-		UserTags:         obj.UserTags,
-		ModTime:          obj.ModTime,
-		VersionID:        obj.VersionID,
-		DeleteMarker:     obj.DeleteMarker,
-		IsLatest:         obj.IsLatest,
-		NumVersions:      obj.NumVersions,
-		SuccessorModTime: obj.SuccessorModTime,
-		RestoreOngoing:   obj.RestoreOngoing,
-		RestoreExpires:   obj.RestoreExpires,
-		TransitionStatus: obj.TransitionStatus,
+		Name:                    obj.Name,
+		UserTags:                obj.UserTags,
+		Size:                    obj.Size,
+		ModTime:                 obj.ModTime,
+		VersionID:               obj.VersionID,
+		DeleteMarker:            obj.DeleteMarker,
+		IsLatest:                obj.IsLatest,
+		NumVersions:             obj.NumVersions,
+		SuccessorModTime:        obj.SuccessorModTime,
+		RestoreOngoing:          obj.RestoreOngoing,
+		RestoreExpires:          obj.RestoreExpires,
+		TransitionStatus:        obj.TransitionStatus,
+		NoncurrentVersionNumber: noncurrentNum,
 	}
 
 	action = lc.ComputeAction(lcOpts)
@@ -978,6 +987,7 @@ func applyExpiryOnTransitionedObject(ctx context.Context, objLayer ObjectLayer,
 	lcOpts := lifecycle.ObjectOpts{
 		Name:             obj.Name,
 		UserTags:         obj.UserTags,
+		Size:             obj.Size,
 		ModTime:          obj.ModTime,
 		VersionID:        obj.VersionID,
 		DeleteMarker:     obj.DeleteMarker,
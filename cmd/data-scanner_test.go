@@ -0,0 +1,86 @@
+/*
+ * MinIO Cloud Storage, (C) 2021 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/bucket/lifecycle"
+)
+
+func TestEvalActionFromLifecycleNewerNoncurrentVersions(t *testing.T) {
+	const (
+		bucket = "bucket"
+		newer  = 2
+	)
+	lc, err := lifecycle.ParseLifecycleConfig(strings.NewReader(fmt.Sprintf(`<LifecycleConfiguration>
+		<Rule>
+			<ID>keep-newer</ID>
+			<Status>Enabled</Status>
+			<Filter></Filter>
+			<NoncurrentVersionExpiration>
+				<NoncurrentDays>1</NoncurrentDays>
+				<NewerNoncurrentVersions>%d</NewerNoncurrentVersions>
+			</NoncurrentVersionExpiration>
+		</Rule>
+	</LifecycleConfiguration>`, newer)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Object lock is looked up before deleting a version, make sure
+	// the bucket has none configured.
+	prevMetadataSys := globalBucketMetadataSys
+	defer func() { globalBucketMetadataSys = prevMetadataSys }()
+	globalBucketMetadataSys = NewBucketMetadataSys()
+	globalBucketMetadataSys.Set(bucket, newBucketMetadata(bucket))
+
+	now := time.Now().UTC()
+	testCases := []struct {
+		noncurrentNum  int
+		successorMod   time.Time
+		expectedAction lifecycle.Action
+	}{
+		// The newest noncurrent versions are retained
+		{noncurrentNum: 1, successorMod: now.Add(-72 * time.Hour), expectedAction: lifecycle.NoneAction},
+		{noncurrentNum: newer, successorMod: now.Add(-72 * time.Hour), expectedAction: lifecycle.NoneAction},
+		// Older ones expire once NoncurrentDays have passed
+		{noncurrentNum: newer + 1, successorMod: now.Add(-72 * time.Hour), expectedAction: lifecycle.DeleteVersionAction},
+		{noncurrentNum: newer + 1, successorMod: now, expectedAction: lifecycle.NoneAction},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			obj := ObjectInfo{
+				Bucket:           bucket,
+				Name:             "object",
+				VersionID:        mustGetUUID(),
+				ModTime:          tc.successorMod.Add(-time.Hour),
+				SuccessorModTime: tc.successorMod,
+				NumVersions:      newer + 2,
+			}
+			action := evalActionFromLifecycle(context.Background(), *lc, obj, tc.noncurrentNum, false)
+			if action != tc.expectedAction {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedAction, action)
+			}
+		})
+	}
+}
@@ -319,7 +319,7 @@ func (fs *FSObjects) scanBucket(ctx context.Context, bucket string, cache dataUs
 	// Get bucket policy
 	// Check if the current bucket has a configured lifecycle policy
 	lc, err := globalLifecycleSys.Get(bucket)
-	if err == nil && lc.HasActiveRules("", true) {
+	if err == nil && lc.HasActiveObjectRules("", true) {
 		if intDataUpdateTracker.debug {
 			logger.Info(color.Green("scanBucket:") + " lifecycle: Active rules found")
 		}
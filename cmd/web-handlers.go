@@ -812,6 +812,7 @@ next:
 				deleteTransitionedObject(ctx, objectAPI, args.BucketName, objectName, lifecycle.ObjectOpts{
 					Name:             objectName,
 					UserTags:         goi.UserTags,
+					Size:             goi.Size,
 					VersionID:        goi.VersionID,
 					DeleteMarker:     goi.DeleteMarker,
 					TransitionStatus: goi.TransitionStatus,
@@ -388,7 +388,7 @@ func (s *xlStorage) NSScanner(ctx context.Context, cache dataUsageCache) (dataUs
 	// Check if the current bucket has a configured lifecycle policy
 	if globalLifecycleSys != nil {
 		lc, err = globalLifecycleSys.Get(cache.Info.Name)
-		if err == nil && lc.HasActiveRules("", true) {
+		if err == nil && lc.HasActiveObjectRules("", true) {
 			cache.Info.lifeCycle = lc
 			if intDataUpdateTracker.debug {
 				console.Debugln(color.Green("scannerDisk:") + " lifecycle: Active rules found")
@@ -433,12 +433,15 @@ func (s *xlStorage) NSScanner(ctx context.Context, cache dataUsageCache) (dataUs
 		var totalSize int64
 
 		sizeS := sizeSummary{}
-		for _, version := range fivs.Versions {
+		for i, version := range fivs.Versions {
 			oi := version.ToObjectInfo(item.bucket, item.objectPath())
 			if objAPI != nil {
+				// Versions are sorted from the latest one, which
+				// is followed by the noncurrent ones.
 				totalSize += item.applyActions(ctx, objAPI, actionMeta{
-					oi:         oi,
-					bitRotScan: healOpts.Bitrot,
+					oi:            oi,
+					bitRotScan:    healOpts.Bitrot,
+					noncurrentNum: i,
 				})
 				item.healReplication(ctx, objAPI, oi.Clone(), &sizeS)
 			}
@@ -0,0 +1,86 @@
+/*
+ * MinIO Cloud Storage, (C) 2021 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lifecycle
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+var (
+	errAbortIncompleteMultipartUploadInvalidDays = Errorf("DaysAfterInitiation must be a positive integer when used with AbortIncompleteMultipartUpload")
+)
+
+// AbortIncompleteMultipartUpload - an action for lifecycle configuration rule.
+type AbortIncompleteMultipartUpload struct {
+	XMLName             xml.Name `xml:"AbortIncompleteMultipartUpload"`
+	DaysAfterInitiation int      `xml:"DaysAfterInitiation,omitempty"`
+	set                 bool
+}
+
+// MarshalXML encodes AbortIncompleteMultipartUpload if it is set
+func (a AbortIncompleteMultipartUpload) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if !a.set {
+		return nil
+	}
+	type abortIncompleteMultipartUploadWrapper AbortIncompleteMultipartUpload
+	return e.EncodeElement(abortIncompleteMultipartUploadWrapper(a), start)
+}
+
+// UnmarshalXML decodes AbortIncompleteMultipartUpload, some S3
+// implementations carry DaysAfterInitiation as an attribute which
+// is used when the child element is not present.
+func (a *AbortIncompleteMultipartUpload) UnmarshalXML(d *xml.Decoder, startElement xml.StartElement) error {
+	type abortIncompleteMultipartUploadWrapper AbortIncompleteMultipartUpload
+	var val abortIncompleteMultipartUploadWrapper
+	err := d.DecodeElement(&val, &startElement)
+	if err != nil {
+		return err
+	}
+	*a = AbortIncompleteMultipartUpload(val)
+	if a.DaysAfterInitiation == 0 {
+		for _, attr := range startElement.Attr {
+			if attr.Name.Local != "DaysAfterInitiation" {
+				continue
+			}
+			days, err := strconv.Atoi(strings.TrimSpace(attr.Value))
+			if err != nil {
+				return errAbortIncompleteMultipartUploadInvalidDays
+			}
+			a.DaysAfterInitiation = days
+		}
+	}
+	a.set = true
+	return nil
+}
+
+// IsDaysNull returns true if days field is null
+func (a AbortIncompleteMultipartUpload) IsDaysNull() bool {
+	return a.DaysAfterInitiation == 0
+}
+
+// Validate returns an error with wrong value
+func (a AbortIncompleteMultipartUpload) Validate() error {
+	if !a.set {
+		return nil
+	}
+	if a.DaysAfterInitiation <= 0 {
+		return errAbortIncompleteMultipartUploadInvalidDays
+	}
+	return nil
+}
@@ -24,14 +24,21 @@ var errDuplicateTagKey = Errorf("Duplicate Tag Keys are not allowed")
 
 // And - a tag to combine a prefix and multiple tags for lifecycle configuration rule.
 type And struct {
-	XMLName xml.Name `xml:"And"`
-	Prefix  Prefix   `xml:"Prefix,omitempty"`
-	Tags    []Tag    `xml:"Tag,omitempty"`
+	XMLName               xml.Name `xml:"And"`
+	Prefix                Prefix   `xml:"Prefix,omitempty"`
+	Tags                  []Tag    `xml:"Tag,omitempty"`
+	ObjectSizeGreaterThan int64    `xml:"ObjectSizeGreaterThan,omitempty"`
+	ObjectSizeLessThan    int64    `xml:"ObjectSizeLessThan,omitempty"`
 }
 
 // isEmpty returns true if Tags field is null
 func (a And) isEmpty() bool {
-	return len(a.Tags) == 0 && !a.Prefix.set
+	return len(a.Tags) == 0 && !a.Prefix.set && !a.sizeSet()
+}
+
+// sizeSet returns true if any object size bound is specified
+func (a And) sizeSet() bool {
+	return a.ObjectSizeGreaterThan != 0 || a.ObjectSizeLessThan != 0
 }
 
 // Validate - validates the And field
@@ -39,11 +46,24 @@ func (a And) Validate() error {
 	emptyPrefix := !a.Prefix.set
 	emptyTags := len(a.Tags) == 0
 
-	if emptyPrefix && emptyTags {
+	if a.isEmpty() {
 		return nil
 	}
 
-	if emptyPrefix && !emptyTags || !emptyPrefix && emptyTags {
+	if a.sizeSet() {
+		if a.ObjectSizeGreaterThan < 0 || a.ObjectSizeLessThan < 0 {
+			return errInvalidObjectSize
+		}
+		if a.ObjectSizeLessThan > 0 && a.ObjectSizeGreaterThan >= a.ObjectSizeLessThan {
+			return errInvalidObjectSizeRange
+		}
+		// And combines at least two predicates
+		if emptyPrefix && emptyTags && (a.ObjectSizeGreaterThan == 0 || a.ObjectSizeLessThan == 0) {
+			return errXMLNotWellFormed
+		}
+	} else if !emptyPrefix && emptyTags {
+		// Prefix is optional, And may hold tags only, but a
+		// Prefix alone must be given directly in Filter.
 		return errXMLNotWellFormed
 	}
 
@@ -0,0 +1,160 @@
+/*
+ * MinIO Cloud Storage, (C) 2021 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lifecycle
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"time"
+)
+
+var errAWSCLIMultipleNoncurrentTransitions = Errorf("Only one NoncurrentVersionTransition is supported per rule")
+
+// The awsCLI types describe the JSON document taken by the AWS CLI
+// put-bucket-lifecycle-configuration command. Their XML tags give the
+// equivalent XML form, which is parsed into and generated from
+// Lifecycle, so that both forms share the same parsing and encoding.
+type awsCLIConfig struct {
+	XMLName xml.Name     `json:"-" xml:"LifecycleConfiguration"`
+	Rules   []awsCLIRule `json:"Rules" xml:"Rule"`
+}
+
+type awsCLIRule struct {
+	ID                             string                       `json:"ID,omitempty" xml:"ID,omitempty"`
+	Status                         string                       `json:"Status" xml:"Status"`
+	Filter                         *awsCLIFilter                `json:"Filter,omitempty" xml:"Filter,omitempty"`
+	Prefix                         *string                      `json:"Prefix,omitempty" xml:"Prefix,omitempty"`
+	Expiration                     *awsCLIExpiration            `json:"Expiration,omitempty" xml:"Expiration,omitempty"`
+	Transitions                    []awsCLITransition           `json:"Transitions,omitempty" xml:"Transition,omitempty"`
+	NoncurrentVersionExpiration    *awsCLINoncurrentExpiration  `json:"NoncurrentVersionExpiration,omitempty" xml:"NoncurrentVersionExpiration,omitempty"`
+	NoncurrentVersionTransitions   []awsCLINoncurrentTransition `json:"NoncurrentVersionTransitions,omitempty" xml:"NoncurrentVersionTransition,omitempty"`
+	AbortIncompleteMultipartUpload *awsCLIAbortMultipartUpload  `json:"AbortIncompleteMultipartUpload,omitempty" xml:"AbortIncompleteMultipartUpload,omitempty"`
+}
+
+type awsCLIFilter struct {
+	Prefix                *string    `json:"Prefix,omitempty" xml:"Prefix,omitempty"`
+	Tag                   *awsCLITag `json:"Tag,omitempty" xml:"Tag,omitempty"`
+	And                   *awsCLIAnd `json:"And,omitempty" xml:"And,omitempty"`
+	ObjectSizeGreaterThan *int64     `json:"ObjectSizeGreaterThan,omitempty" xml:"ObjectSizeGreaterThan,omitempty"`
+	ObjectSizeLessThan    *int64     `json:"ObjectSizeLessThan,omitempty" xml:"ObjectSizeLessThan,omitempty"`
+}
+
+type awsCLIAnd struct {
+	Prefix                *string     `json:"Prefix,omitempty" xml:"Prefix,omitempty"`
+	Tags                  []awsCLITag `json:"Tags,omitempty" xml:"Tag,omitempty"`
+	ObjectSizeGreaterThan *int64      `json:"ObjectSizeGreaterThan,omitempty" xml:"ObjectSizeGreaterThan,omitempty"`
+	ObjectSizeLessThan    *int64      `json:"ObjectSizeLessThan,omitempty" xml:"ObjectSizeLessThan,omitempty"`
+}
+
+type awsCLITag struct {
+	Key   string `json:"Key" xml:"Key"`
+	Value string `json:"Value" xml:"Value"`
+}
+
+type awsCLIExpiration struct {
+	Date                      *string `json:"Date,omitempty" xml:"Date,omitempty"`
+	Days                      *int    `json:"Days,omitempty" xml:"Days,omitempty"`
+	ExpiredObjectDeleteMarker *bool   `json:"ExpiredObjectDeleteMarker,omitempty" xml:"ExpiredObjectDeleteMarker,omitempty"`
+}
+
+type awsCLITransition struct {
+	Date         *string `json:"Date,omitempty" xml:"Date,omitempty"`
+	Days         *int    `json:"Days,omitempty" xml:"Days,omitempty"`
+	StorageClass string  `json:"StorageClass" xml:"StorageClass"`
+}
+
+type awsCLINoncurrentExpiration struct {
+	NoncurrentDays          *int `json:"NoncurrentDays,omitempty" xml:"NoncurrentDays,omitempty"`
+	NewerNoncurrentVersions *int `json:"NewerNoncurrentVersions,omitempty" xml:"NewerNoncurrentVersions,omitempty"`
+}
+
+type awsCLINoncurrentTransition struct {
+	NoncurrentDays *int   `json:"NoncurrentDays,omitempty" xml:"NoncurrentDays,omitempty"`
+	StorageClass   string `json:"StorageClass" xml:"StorageClass"`
+}
+
+type awsCLIAbortMultipartUpload struct {
+	DaysAfterInitiation *int `json:"DaysAfterInitiation,omitempty" xml:"DaysAfterInitiation,omitempty"`
+}
+
+// ParseAWSCLIJSON parses a lifecycle configuration in the JSON form taken
+// by the AWS CLI put-bucket-lifecycle-configuration command, e.g.
+// {"Rules":[{"ID":"id","Status":"Enabled","Transitions":[...]}]}, the
+// same way ParseLifecycleConfig parses the XML form. MinIO supports at
+// most one NoncurrentVersionTransitions entry per rule.
+func ParseAWSCLIJSON(data []byte) (Lifecycle, error) {
+	var config awsCLIConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Lifecycle{}, err
+	}
+	for _, r := range config.Rules {
+		if len(r.NoncurrentVersionTransitions) > 1 {
+			return Lifecycle{}, errAWSCLIMultipleNoncurrentTransitions
+		}
+	}
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return Lifecycle{}, err
+	}
+	lc, err := ParseLifecycleConfig(bytes.NewReader(buf))
+	if err != nil {
+		return Lifecycle{}, err
+	}
+	return *lc, nil
+}
+
+// ToAWSCLIJSON returns the configuration in the JSON form taken by the AWS
+// CLI put-bucket-lifecycle-configuration command, the counterpart of
+// ParseAWSCLIJSON. Dates are written in RFC 3339 form, e.g.
+// 2030-01-01T00:00:00Z, whatever form they were parsed from.
+func (lc Lifecycle) ToAWSCLIJSON() ([]byte, error) {
+	buf, err := xml.Marshal(lc)
+	if err != nil {
+		return nil, err
+	}
+	var config awsCLIConfig
+	if err = xml.Unmarshal(buf, &config); err != nil {
+		return nil, err
+	}
+	if config.Rules == nil {
+		config.Rules = []awsCLIRule{}
+	}
+	for i := range config.Rules {
+		r := &config.Rules[i]
+		if r.Expiration != nil {
+			normalizeAWSCLIDate(r.Expiration.Date)
+		}
+		for j := range r.Transitions {
+			normalizeAWSCLIDate(r.Transitions[j].Date)
+		}
+	}
+	return json.Marshal(config)
+}
+
+// normalizeAWSCLIDate rewrites a date in RFC 3339 form in UTC.
+func normalizeAWSCLIDate(date *string) {
+	if date == nil {
+		return
+	}
+	for _, layout := range []string{time.RFC3339, transitionDateOnlyLayout} {
+		if t, err := time.Parse(layout, *date); err == nil {
+			*date = t.UTC().Format(time.RFC3339)
+			return
+		}
+	}
+}
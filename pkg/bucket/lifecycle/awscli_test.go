@@ -0,0 +1,180 @@
+/*
+ * MinIO Cloud Storage, (C) 2021 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lifecycle
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+const awsCLIConfigJSON = `{
+    "Rules": [
+        {
+            "ID": "archive-logs",
+            "Filter": {
+                "And": {
+                    "Prefix": "logs/",
+                    "Tags": [
+                        {"Key": "tier", "Value": "cold"},
+                        {"Key": "team", "Value": "infra"}
+                    ]
+                }
+            },
+            "Status": "Enabled",
+            "Transitions": [
+                {"Days": 30, "StorageClass": "STANDARD_IA"},
+                {"Days": 90, "StorageClass": "GLACIER"}
+            ],
+            "Expiration": {"Days": 365},
+            "NoncurrentVersionTransitions": [
+                {"NoncurrentDays": 10, "StorageClass": "GLACIER"}
+            ],
+            "NoncurrentVersionExpiration": {"NoncurrentDays": 30}
+        },
+        {
+            "ID": "expire-tmp",
+            "Filter": {"Prefix": "tmp/"},
+            "Status": "Disabled",
+            "Transitions": [
+                {"Date": "2030-01-01T00:00:00Z", "StorageClass": "GLACIER"}
+            ],
+            "Expiration": {"Date": "2031-01-01T00:00:00Z"}
+        },
+        {
+            "ID": "cleanup",
+            "Filter": {"Prefix": ""},
+            "Status": "Enabled",
+            "Expiration": {"ExpiredObjectDeleteMarker": true},
+            "AbortIncompleteMultipartUpload": {"DaysAfterInitiation": 7}
+        }
+    ]
+}`
+
+func TestParseAWSCLIJSON(t *testing.T) {
+	lc, err := ParseAWSCLIJSON([]byte(awsCLIConfigJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = lc.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(lc.Rules) != 3 {
+		t.Fatalf("Expected 3 rules but got %d", len(lc.Rules))
+	}
+
+	r := lc.Rules[0]
+	if r.ID != "archive-logs" || r.Status != Enabled || r.GetPrefix() != "logs/" || r.Tags() != "tier=cold&team=infra" {
+		t.Fatalf("Unexpected rule %v", r)
+	}
+	if len(r.Transitions) != 2 || r.Transitions[0].Days != 30 || r.Transitions[0].StorageClass != "STANDARD_IA" ||
+		r.Transitions[1].Days != 90 || r.Transitions[1].StorageClass != "GLACIER" {
+		t.Fatalf("Unexpected transitions %v", r.Transitions)
+	}
+	if r.Expiration.Days != 365 || r.NoncurrentVersionExpiration.NoncurrentDays != 30 ||
+		r.NoncurrentVersionTransition.NoncurrentDays != 10 || r.NoncurrentVersionTransition.StorageClass != "GLACIER" {
+		t.Fatalf("Unexpected rule %v", r)
+	}
+
+	r = lc.Rules[1]
+	date := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if r.Status != Disabled || r.GetPrefix() != "tmp/" || len(r.Transitions) != 1 || !r.Transitions[0].Date.Equal(date) ||
+		!r.Expiration.Date.Equal(date.AddDate(1, 0, 0)) {
+		t.Fatalf("Unexpected rule %v", r)
+	}
+
+	r = lc.Rules[2]
+	if !r.Expiration.DeleteMarker.val || r.AbortIncompleteMultipartUpload.DaysAfterInitiation != 7 {
+		t.Fatalf("Unexpected rule %v", r)
+	}
+}
+
+func TestParseAWSCLIJSONErrors(t *testing.T) {
+	testCases := []struct {
+		input       string
+		expectedErr error
+	}{
+		{
+			input:       `{"Rules": [{"ID": "rule", "Status": "Enabled", "Filter": {"Prefix": ""}, "NoncurrentVersionTransitions": [{"NoncurrentDays": 10, "StorageClass": "STANDARD_IA"}, {"NoncurrentDays": 30, "StorageClass": "GLACIER"}]}]}`,
+			expectedErr: errAWSCLIMultipleNoncurrentTransitions,
+		},
+		{
+			input:       `{"Rules": [{"ID": "rule", "Status": "Enabled", "Filter": {"Prefix": ""}, "Expiration": {"Days": -1}}]}`,
+			expectedErr: errLifecycleInvalidDays,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if _, err := ParseAWSCLIJSON([]byte(tc.input)); err != tc.expectedErr {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+		})
+	}
+
+	if _, err := ParseAWSCLIJSON([]byte(`{"Rules": [`)); err == nil {
+		t.Fatal("Expected an error parsing truncated input")
+	}
+}
+
+func TestAWSCLIJSONRoundTrip(t *testing.T) {
+	lc, err := ParseAWSCLIJSON([]byte(awsCLIConfigJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := lc.ToAWSCLIJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plc, err := ParseAWSCLIJSON(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plc.String() != lc.String() {
+		t.Fatalf("Expected %s but got %s", lc, plc)
+	}
+	second, err := plc.ToAWSCLIJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != string(second) {
+		t.Fatalf("Expected\n%s\nbut got\n%s", first, second)
+	}
+
+	expected := `{"Rules":[` +
+		`{"ID":"archive-logs","Status":"Enabled","Filter":{"And":{"Prefix":"logs/","Tags":[{"Key":"tier","Value":"cold"},{"Key":"team","Value":"infra"}]}},` +
+		`"Expiration":{"Days":365},"Transitions":[{"Days":30,"StorageClass":"STANDARD_IA"},{"Days":90,"StorageClass":"GLACIER"}],` +
+		`"NoncurrentVersionExpiration":{"NoncurrentDays":30},"NoncurrentVersionTransitions":[{"NoncurrentDays":10,"StorageClass":"GLACIER"}]},` +
+		`{"ID":"expire-tmp","Status":"Disabled","Filter":{"Prefix":"tmp/"},"Expiration":{"Date":"2031-01-01T00:00:00Z"},"Transitions":[{"Date":"2030-01-01T00:00:00Z","StorageClass":"GLACIER"}]},` +
+		`{"ID":"cleanup","Status":"Enabled","Filter":{"Prefix":""},"Expiration":{"ExpiredObjectDeleteMarker":true},"AbortIncompleteMultipartUpload":{"DaysAfterInitiation":7}}` +
+		`]}`
+	if string(first) != expected {
+		t.Fatalf("Expected\n%s\nbut got\n%s", expected, first)
+	}
+
+	// Date only transition dates are normalized
+	lc, err = ParseAWSCLIJSON([]byte(`{"Rules": [{"ID": "rule", "Status": "Enabled", "Filter": {"Prefix": ""}, "Transitions": [{"Date": "2030-01-01", "StorageClass": "GLACIER"}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := lc.ToAWSCLIJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected = `{"Rules":[{"ID":"rule","Status":"Enabled","Filter":{"Prefix":""},"Transitions":[{"Date":"2030-01-01T00:00:00Z","StorageClass":"GLACIER"}]}]}`; string(out) != expected {
+		t.Fatalf("Expected %s but got %s", expected, out)
+	}
+}
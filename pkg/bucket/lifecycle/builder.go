@@ -0,0 +1,154 @@
+/*
+ * MinIO Cloud Storage, (C) 2021 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lifecycle
+
+import (
+	"time"
+)
+
+// RuleBuilder constructs a Rule programmatically, setting the
+// internal flags the XML decoder would otherwise set. The first
+// error encountered is kept and returned by Build.
+type RuleBuilder struct {
+	id         string
+	status     Status
+	prefix     string
+	tags       []Tag
+	expiration Expiration
+	abort      AbortIncompleteMultipartUpload
+	trs        []Transition
+	err        error
+}
+
+// NewRule returns a builder for an enabled rule with the given ID,
+// applying to all objects in the bucket unless narrowed down with
+// WithPrefix or WithTag.
+func NewRule(id string) *RuleBuilder {
+	return &RuleBuilder{id: id, status: Enabled}
+}
+
+// Disabled marks the rule as disabled.
+func (b *RuleBuilder) Disabled() *RuleBuilder {
+	b.status = Disabled
+	return b
+}
+
+// WithPrefix restricts the rule to objects with the given prefix.
+func (b *RuleBuilder) WithPrefix(prefix string) *RuleBuilder {
+	b.prefix = prefix
+	return b
+}
+
+// WithTag restricts the rule to objects carrying the given tag.
+func (b *RuleBuilder) WithTag(key, value string) *RuleBuilder {
+	b.tags = append(b.tags, Tag{Key: key, Value: value})
+	return b
+}
+
+// ExpireAfterDays expires objects once they are days old.
+func (b *RuleBuilder) ExpireAfterDays(days int) *RuleBuilder {
+	if days <= 0 {
+		b.setErr(errLifecycleInvalidDays)
+		return b
+	}
+	b.expiration = Expiration{Days: ExpirationDays(days), set: true}
+	return b
+}
+
+// ExpireOnDate expires objects on date, which must be at midnight GMT.
+func (b *RuleBuilder) ExpireOnDate(date time.Time) *RuleBuilder {
+	if date.IsZero() {
+		b.setErr(errLifecycleInvalidDate)
+		return b
+	}
+	if !isMidnightGMT(date) {
+		b.setErr(errLifecycleDateNotMidnight)
+		return b
+	}
+	b.expiration = Expiration{Date: ExpirationDate{date}, set: true}
+	return b
+}
+
+// TransitionAfterDays adds a transition tier to storageClass once
+// objects are days old.
+func (b *RuleBuilder) TransitionAfterDays(days int, storageClass string) *RuleBuilder {
+	t, err := NewTransitionDays(days, storageClass)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.trs = append(b.trs, t)
+	return b
+}
+
+// TransitionOnDate adds a transition tier to storageClass on date,
+// which must be at midnight GMT.
+func (b *RuleBuilder) TransitionOnDate(date time.Time, storageClass string) *RuleBuilder {
+	t, err := NewTransitionDate(date, storageClass)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.trs = append(b.trs, t)
+	return b
+}
+
+// AbortIncompleteMultipartUploadAfterDays aborts multipart uploads
+// which are not completed days after they were initiated.
+func (b *RuleBuilder) AbortIncompleteMultipartUploadAfterDays(days int) *RuleBuilder {
+	b.abort = AbortIncompleteMultipartUpload{DaysAfterInitiation: days, set: true}
+	return b
+}
+
+func (b *RuleBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Build returns the validated rule, or the first error encountered
+// while building it.
+func (b *RuleBuilder) Build() (Rule, error) {
+	if b.err != nil {
+		return Rule{}, b.err
+	}
+	r := Rule{
+		ID:                             b.id,
+		Status:                         b.status,
+		Expiration:                     b.expiration,
+		AbortIncompleteMultipartUpload: b.abort,
+	}
+	if len(b.trs) > 0 {
+		r.Transitions = append([]Transition(nil), b.trs...)
+	}
+	prefix := Prefix{string: b.prefix, set: true}
+	switch {
+	case len(b.tags) == 0:
+		r.Filter = Filter{Prefix: prefix}
+	case len(b.tags) == 1 && b.prefix == "":
+		r.Filter = Filter{Tag: b.tags[0], tagSet: true}
+	default:
+		r.Filter = Filter{
+			And:    And{Prefix: prefix, Tags: append([]Tag(nil), b.tags...)},
+			andSet: true,
+		}
+	}
+	if err := r.Validate(); err != nil {
+		return Rule{}, err
+	}
+	return r, nil
+}
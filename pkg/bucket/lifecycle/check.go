@@ -0,0 +1,123 @@
+/*
+ * MinIO Cloud Storage, (C) 2021 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lifecycle
+
+import (
+	"errors"
+	"strings"
+)
+
+// ValidationResult describes a single validation failure of a lifecycle
+// configuration in a form suitable for machine consumers.
+type ValidationResult struct {
+	// RuleIndex is the index of the offending rule, or -1 if the
+	// failure concerns the configuration as a whole.
+	RuleIndex int `json:"ruleIndex"`
+	// RuleID is the ID of the offending rule, if any.
+	RuleID string `json:"ruleID,omitempty"`
+	// Field is the dot separated path of the offending element within
+	// the rule, e.g. Transition[0].StorageClass
+	Field string `json:"field,omitempty"`
+	// Code is a stable identifier of the failure, e.g. MissingStorageClass
+	Code string `json:"code"`
+	// Message is the human readable description of the failure.
+	Message string `json:"message"`
+	// Warning is true if the failure does not make the configuration
+	// invalid, see IsWarning.
+	Warning bool `json:"warning,omitempty"`
+}
+
+// validationCodes maps validation errors to their stable codes,
+// the first matching entry wins.
+var validationCodes = []struct {
+	err  error
+	code string
+}{
+	{errLifecycleTooManyRules, "TooManyRules"},
+	{errLifecycleDuplicateID, "DuplicateRuleID"},
+	{errLifecycleRuleMissingID, "MissingRuleID"},
+	{errLifecycleConflictingRules, "ConflictingRules"},
+	{errInvalidRuleID, "InvalidRuleID"},
+	{errEmptyRuleStatus, "MissingStatus"},
+	{errInvalidRuleStatus, "InvalidStatus"},
+	{errRuleNoAction, "NoAction"},
+	{errRulePrefixWithFilter, "PrefixWithFilter"},
+	{errAbortIncompleteMultipartUploadWithTags, "AbortIncompleteMultipartUploadWithTags"},
+	{errExpiredObjectDeleteMarkerWithTags, "ExpiredObjectDeleteMarkerWithTags"},
+	{errAbortIncompleteMultipartUploadInvalidDays, "InvalidDays"},
+	{errInvalidFilter, "InvalidFilter"},
+	{errInvalidFilterObjectSize, "InvalidFilter"},
+	{errInvalidObjectSize, "InvalidObjectSize"},
+	{errInvalidObjectSizeRange, "InvalidObjectSizeRange"},
+	{errDuplicateTagKey, "DuplicateTagKey"},
+	{errInvalidTagKey, "InvalidTagKey"},
+	{errInvalidTagValue, "InvalidTagValue"},
+	{errLifecycleInvalidDate, "InvalidDate"},
+	{errLifecycleInvalidDays, "InvalidDays"},
+	{errLifecycleInvalidExpiration, "InvalidExpiration"},
+	{errLifecycleInvalidDeleteMarker, "InvalidDeleteMarker"},
+	{errLifecycleDateNotMidnight, "DateNotMidnight"},
+	{errNoncurrentInvalidNewerVersions, "InvalidNewerNoncurrentVersions"},
+	{errNoncurrentNewerVersionsNoDays, "MissingNoncurrentDays"},
+	{errTransitionInvalidDays, "InvalidDays"},
+	{errTransitionDaysTooLarge, "DaysTooLarge"},
+	{errTransitionInvalidDate, "InvalidDate"},
+	{errTransitionInvalid, "InvalidTransition"},
+	{errTransitionDateNotMidnight, "DateNotMidnight"},
+	{errTransitionInvalidStorageClass, "InvalidStorageClass"},
+	{errTransitionDateInPast, "DateInPast"},
+	{errTransitionDuplicateStorageClass, "DuplicateStorageClass"},
+	{errTransitionNotMonotonic, "TransitionsNotMonotonic"},
+	{errTransitionNotColder, "TransitionsNotColder"},
+	{errTransitionNotBeforeExpiration, "TransitionNotBeforeExpiration"},
+	{errXMLNotWellFormed, "MalformedXML"},
+}
+
+// validationCode returns the stable code of a validation error
+// found at field.
+func validationCode(field string, err error) string {
+	if errors.Is(err, errXMLNotWellFormed) && (field == "StorageClass" || strings.HasSuffix(field, ".StorageClass")) {
+		return "MissingStorageClass"
+	}
+	for _, c := range validationCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return "InvalidLifecycle"
+}
+
+// Check validates the lifecycle configuration like ValidateAll, returning
+// every failure, including warnings, as a ValidationResult.
+func (lc Lifecycle) Check() []ValidationResult {
+	var results []ValidationResult
+	for _, err := range lc.ValidateAll() {
+		result := ValidationResult{RuleIndex: -1, Message: err.Error(), Warning: IsWarning(err)}
+		var ve ValidationError
+		if errors.As(err, &ve) {
+			result.RuleIndex = ve.RuleIndex
+			result.Field = ve.Element
+			result.Message = ve.Err.Error()
+		}
+		if result.RuleIndex >= 0 && result.RuleIndex < len(lc.Rules) {
+			result.RuleID = lc.Rules[result.RuleIndex].ID
+		}
+		result.Code = validationCode(result.Field, err)
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,156 @@
+/*
+ * MinIO Cloud Storage, (C) 2021 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestLifecycleCheck(t *testing.T) {
+	valid := Rule{
+		ID:         "expire",
+		Status:     "Enabled",
+		Filter:     Filter{Prefix: Prefix{string: "logs/", set: true}},
+		Expiration: Expiration{Days: ExpirationDays(30), set: true},
+	}
+	missingStorageClass := Rule{
+		ID:          "archive",
+		Status:      "Enabled",
+		Filter:      Filter{Prefix: Prefix{string: "data/", set: true}},
+		Transitions: []Transition{{Days: 30, daysSet: true, set: true}},
+	}
+	duplicateID := valid
+	noStatus := Rule{
+		ID:         "nostatus",
+		Filter:     Filter{Prefix: Prefix{string: "tmp/", set: true}},
+		Expiration: Expiration{Days: ExpirationDays(30), set: true},
+	}
+
+	testCases := []struct {
+		lc       Lifecycle
+		expected []ValidationResult
+	}{
+		{
+			lc: Lifecycle{Rules: []Rule{valid}},
+		},
+		{ // A configuration without rules is valid
+			lc: Lifecycle{},
+		},
+		{
+			lc: Lifecycle{Rules: []Rule{valid, missingStorageClass, duplicateID, noStatus}},
+			expected: []ValidationResult{
+				{RuleIndex: 1, RuleID: "archive", Field: "Transition[0].StorageClass", Code: "MissingStorageClass", Message: errXMLNotWellFormed.Error()},
+				{RuleIndex: 2, RuleID: "expire", Field: "ID", Code: "DuplicateRuleID", Message: errLifecycleDuplicateID.Error()},
+				{RuleIndex: 3, RuleID: "nostatus", Field: "Status", Code: "MissingStatus", Message: errEmptyRuleStatus.Error()},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			got := tc.lc.Check()
+			if len(got) != len(tc.expected) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected, got)
+			}
+			for j := range got {
+				if got[j] != tc.expected[j] {
+					t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected[j], got[j])
+				}
+			}
+		})
+	}
+}
+
+func TestValidationCode(t *testing.T) {
+	testCases := []struct {
+		field    string
+		err      error
+		expected string
+	}{
+		{"Expiration", errLifecycleDateNotMidnight, "DateNotMidnight"},
+		{"Transition[0]", errTransitionDateNotMidnight, "DateNotMidnight"},
+		{"Transition[0].StorageClass", errXMLNotWellFormed, "MissingStorageClass"},
+		{"Transition[0]", errXMLNotWellFormed, "MalformedXML"},
+		{"", withRuleIndex(0, withElement("ID", errInvalidRuleID)), "InvalidRuleID"},
+		{"", Errorf("unknown"), "InvalidLifecycle"},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if got := validationCode(tc.field, tc.err); got != tc.expected {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTransitionNotBeforeExpirationWarning(t *testing.T) {
+	rule := func(transitionDays, expirationDays int) Rule {
+		return Rule{
+			ID:          "rule",
+			Status:      "Enabled",
+			Filter:      Filter{Prefix: Prefix{string: "logs/", set: true}},
+			Expiration:  Expiration{Days: ExpirationDays(expirationDays), set: true},
+			Transitions: []Transition{{Days: TransitionDays(transitionDays), StorageClass: "GLACIER", daysSet: true, set: true}},
+		}
+	}
+	testCases := []struct {
+		rule         Rule
+		expectedWarn bool
+	}{
+		{rule(30, 30), true},
+		{rule(40, 30), true},
+		{rule(20, 60), false},
+		{rule(29, 30), false},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			lc := Lifecycle{Rules: []Rule{tc.rule}}
+			// A warning never fails validation
+			if err := lc.Validate(); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			errs := lc.ValidateAll()
+			results := lc.Check()
+			if !tc.expectedWarn {
+				if len(errs) != 0 || len(results) != 0 {
+					t.Fatalf("%d: Expected no warning but got %v", i+1, results)
+				}
+				return
+			}
+			if len(errs) != 1 || !IsWarning(errs[0]) || !errors.Is(errs[0], errTransitionNotBeforeExpiration) {
+				t.Fatalf("%d: Expected a warning but got %v", i+1, errs)
+			}
+			expected := ValidationResult{
+				RuleIndex: 0,
+				RuleID:    "rule",
+				Field:     "Transition[0].Days",
+				Code:      "TransitionNotBeforeExpiration",
+				Message:   errTransitionNotBeforeExpiration.Error(),
+				Warning:   true,
+			}
+			if len(results) != 1 || results[0] != expected {
+				t.Fatalf("%d: Expected %v but got %v", i+1, expected, results)
+			}
+		})
+	}
+
+	if IsWarning(errTransitionNotMonotonic) {
+		t.Fatal("Expected an error not to be a warning")
+	}
+}
@@ -0,0 +1,149 @@
+/*
+ * MinIO Cloud Storage, (C) 2021 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lifecycle
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ChangeType is the kind of change made to a rule, see Diff.
+type ChangeType string
+
+// Kinds of changes made to a rule
+const (
+	RuleAdded    ChangeType = "added"
+	RuleRemoved  ChangeType = "removed"
+	RuleModified ChangeType = "modified"
+)
+
+// Change describes how a rule differs between two configurations.
+type Change struct {
+	Type   ChangeType
+	RuleID string
+	// Index is the position of the rule in the previous configuration,
+	// or in the next one for an added rule.
+	Index int
+	// Fields lists the elements of a modified rule which differ,
+	// e.g. Status, Filter or Transition[0].
+	Fields []string
+}
+
+// String returns a human readable form of the change, e.g.
+// modified rule "logs": Expiration, Transition[0]. A rule without
+// ID is referred to by its position, e.g. removed rule #2.
+func (c Change) String() string {
+	s := fmt.Sprintf("%s rule %q", c.Type, c.RuleID)
+	if c.RuleID == "" {
+		s = fmt.Sprintf("%s rule #%d", c.Type, c.Index+1)
+	}
+	if len(c.Fields) > 0 {
+		s += ": " + strings.Join(c.Fields, ", ")
+	}
+	return s
+}
+
+// diffKeys returns the keys matching rules across configurations: the
+// rule ID, or for a rule without ID its position among such rules.
+func diffKeys(rules []Rule) []string {
+	keys := make([]string, len(rules))
+	var unnamed int
+	for i, r := range rules {
+		if r.ID != "" {
+			keys[i] = "id:" + r.ID
+			continue
+		}
+		unnamed++
+		keys[i] = "#" + strconv.Itoa(unnamed)
+	}
+	return keys
+}
+
+// Diff returns the changes turning prev into next, matching rules by ID,
+// and rules without ID by their order among rules without ID. Removed
+// and modified rules come first, in the order of prev, followed by
+// added rules in the order of next.
+func Diff(prev, next Lifecycle) []Change {
+	prevKeys, nextKeys := diffKeys(prev.Rules), diffKeys(next.Rules)
+	nextRules := make(map[string]int, len(next.Rules))
+	for i, key := range nextKeys {
+		nextRules[key] = i
+	}
+	prevRules := make(map[string]struct{}, len(prev.Rules))
+	var changes []Change
+	for i, p := range prev.Rules {
+		prevRules[prevKeys[i]] = struct{}{}
+		j, ok := nextRules[prevKeys[i]]
+		if !ok {
+			changes = append(changes, Change{Type: RuleRemoved, RuleID: p.ID, Index: i})
+			continue
+		}
+		if fields := ruleFieldsDiff(p, next.Rules[j]); len(fields) > 0 {
+			changes = append(changes, Change{Type: RuleModified, RuleID: p.ID, Index: i, Fields: fields})
+		}
+	}
+	for j, n := range next.Rules {
+		if _, ok := prevRules[nextKeys[j]]; !ok {
+			changes = append(changes, Change{Type: RuleAdded, RuleID: n.ID, Index: j})
+		}
+	}
+	return changes
+}
+
+// ruleFieldsDiff returns the elements which differ between two rules.
+func ruleFieldsDiff(a, b Rule) []string {
+	a.normalizeLegacyPrefix()
+	b.normalizeLegacyPrefix()
+	var fields []string
+	if a.Status != b.Status {
+		fields = append(fields, "Status")
+	}
+	if !a.Filter.Equals(b.Filter) {
+		fields = append(fields, "Filter")
+	}
+	if !xmlEqual(a.Expiration, b.Expiration) {
+		fields = append(fields, "Expiration")
+	}
+	for i := 0; i < len(a.Transitions) || i < len(b.Transitions); i++ {
+		if i >= len(a.Transitions) || i >= len(b.Transitions) || !a.Transitions[i].Equals(b.Transitions[i]) {
+			fields = append(fields, "Transition["+strconv.Itoa(i)+"]")
+		}
+	}
+	if !xmlEqual(a.NoncurrentVersionExpiration, b.NoncurrentVersionExpiration) {
+		fields = append(fields, "NoncurrentVersionExpiration")
+	}
+	if !xmlEqual(a.NoncurrentVersionTransition, b.NoncurrentVersionTransition) {
+		fields = append(fields, "NoncurrentVersionTransition")
+	}
+	if !xmlEqual(a.AbortIncompleteMultipartUpload, b.AbortIncompleteMultipartUpload) {
+		fields = append(fields, "AbortIncompleteMultipartUpload")
+	}
+	if !xmlEqual(a.RawExtra, b.RawExtra) {
+		fields = append(fields, "RawExtra")
+	}
+	return fields
+}
+
+// xmlEqual returns true if a and b have the same XML encoding.
+func xmlEqual(a, b interface{}) bool {
+	ab, aErr := xml.Marshal(a)
+	bb, bErr := xml.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(ab, bb)
+}
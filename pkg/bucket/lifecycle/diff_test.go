@@ -0,0 +1,120 @@
+/*
+ * MinIO Cloud Storage, (C) 2021 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lifecycle
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	parse := func(s string) Lifecycle {
+		lc, err := ParseLifecycleConfig(strings.NewReader(`<LifecycleConfiguration>` + s + `</LifecycleConfiguration>`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *lc
+	}
+	old := parse(`<Rule><ID>logs</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>30</Days></Expiration>` +
+		`<Transition><Days>10</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>` +
+		`<Rule><ID>tmp</ID><Status>Enabled</Status><Filter><Prefix>tmp/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>` +
+		`<Rule><ID>legacy</ID><Status>Enabled</Status><Prefix>old/</Prefix><Expiration><Days>7</Days></Expiration></Rule>`)
+	next := parse(`<Rule><ID>legacy</ID><Status>Enabled</Status><Filter><Prefix>old/</Prefix></Filter><Expiration><Days>7</Days></Expiration></Rule>` +
+		`<Rule><ID>logs</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>60</Days></Expiration>` +
+		`<Transition><Days>10</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>` +
+		`<Rule><ID>uploads</ID><Status>Enabled</Status><Filter><Prefix>uploads/</Prefix></Filter><AbortIncompleteMultipartUpload><DaysAfterInitiation>2</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule>`)
+
+	changes := Diff(old, next)
+	expected := []string{
+		`modified rule "logs": Expiration`,
+		`removed rule "tmp"`,
+		`added rule "uploads"`,
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %v but got %v", expected, changes)
+	}
+	for i, c := range changes {
+		if c.String() != expected[i] {
+			t.Fatalf("%d: Expected %s but got %s", i+1, expected[i], c)
+		}
+	}
+	if changes[0].Type != RuleModified || changes[1].Type != RuleRemoved || changes[2].Type != RuleAdded {
+		t.Fatalf("Unexpected change types %v", changes)
+	}
+
+	// Status and transition changes
+	next.Rules[1].Status = Disabled
+	next.Rules[1].Transitions = append(next.Rules[1].Transitions, Transition{Days: 90, StorageClass: "DEEP_ARCHIVE", daysSet: true, set: true})
+	if changes = Diff(old, next); changes[0].String() != `modified rule "logs": Status, Expiration, Transition[1]` {
+		t.Fatalf("Unexpected change %s", changes[0])
+	}
+
+	if changes = Diff(old, old); len(changes) != 0 {
+		t.Fatalf("Expected no changes but got %v", changes)
+	}
+}
+
+// TestDiffUnnamedRules checks if rules without ID are matched by their
+// order and if unknown elements are compared
+func TestDiffUnnamedRules(t *testing.T) {
+	parse := func(s string) Lifecycle {
+		lc, err := ParseLifecycleConfig(strings.NewReader(`<LifecycleConfiguration>` + s + `</LifecycleConfiguration>`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *lc
+	}
+	prev := parse(`<Rule><Status>Enabled</Status><Filter><Prefix>a/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>` +
+		`<Rule><ID>named</ID><Status>Enabled</Status><Filter><Prefix>n/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>` +
+		`<Rule><Status>Enabled</Status><Filter><Prefix>b/</Prefix></Filter><Expiration><Days>1</Days></Expiration><VendorExt v="1"/></Rule>`)
+
+	testCases := []struct {
+		next     string
+		expected []string
+	}{
+		{ // Same rules, the named one moved
+			next: `<Rule><ID>named</ID><Status>Enabled</Status><Filter><Prefix>n/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>` +
+				`<Rule><Status>Enabled</Status><Filter><Prefix>a/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>` +
+				`<Rule><Status>Enabled</Status><Filter><Prefix>b/</Prefix></Filter><Expiration><Days>1</Days></Expiration><VendorExt v="1"/></Rule>`,
+		},
+		{
+			next: `<Rule><Status>Enabled</Status><Filter><Prefix>a/</Prefix></Filter><Expiration><Days>2</Days></Expiration></Rule>` +
+				`<Rule><ID>named</ID><Status>Enabled</Status><Filter><Prefix>n/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>`,
+			expected: []string{`modified rule #1: Expiration`, `removed rule #3`},
+		},
+		{
+			next: `<Rule><Status>Enabled</Status><Filter><Prefix>a/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>` +
+				`<Rule><Status>Enabled</Status><Filter><Prefix>b/</Prefix></Filter><Expiration><Days>1</Days></Expiration><VendorExt v="2"/></Rule>` +
+				`<Rule><Status>Enabled</Status><Filter><Prefix>c/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>`,
+			expected: []string{`removed rule "named"`, `modified rule #3: RawExtra`, `added rule #3`},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			changes := Diff(prev, parse(tc.next))
+			if len(changes) != len(tc.expected) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected, changes)
+			}
+			for j, c := range changes {
+				if c.String() != tc.expected[j] {
+					t.Fatalf("%d: Expected %s but got %s", i+1, tc.expected[j], c)
+				}
+			}
+		})
+	}
+}
@@ -17,7 +17,9 @@
 package lifecycle
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 )
 
 // Error is the generic type for any error happening during tag
@@ -42,3 +44,118 @@ func (e Error) Error() string {
 	}
 	return e.err.Error()
 }
+
+// ValidationError reports which element of a lifecycle configuration
+// failed validation, it is always returned wrapped in an Error.
+type ValidationError struct {
+	// RuleIndex is the index of the offending rule in the
+	// configuration, or -1 if it is not known.
+	RuleIndex int
+	// Element is the dot separated path of the offending
+	// element within the rule, e.g. Transition[0].StorageClass
+	Element string
+	Err     error
+}
+
+// Path returns the full path of the offending element,
+// e.g. Rule[2].Transition[0].StorageClass
+func (e ValidationError) Path() string {
+	if e.RuleIndex < 0 {
+		return e.Element
+	}
+	path := "Rule[" + strconv.Itoa(e.RuleIndex) + "]"
+	if e.Element != "" {
+		path += "." + e.Element
+	}
+	return path
+}
+
+// Unwrap the internal error.
+func (e ValidationError) Unwrap() error { return e.Err }
+
+// Error 'error' compatible method, it returns the message of the
+// underlying error, see Detail for the message with the path.
+func (e ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+// Detail returns the message of the underlying error prefixed with the
+// path of the offending element, e.g.
+// "Rule[0].Filter: Filter must have exactly one of Prefix, Tag, or And specified"
+func (e ValidationError) Detail() string {
+	if path := e.Path(); path != "" {
+		return path + ": " + e.Err.Error()
+	}
+	return e.Err.Error()
+}
+
+// withElement annotates a validation error with the element it was
+// found in, prepending it to the path of any nested element.
+func withElement(element string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var ve ValidationError
+	if !errors.As(err, &ve) {
+		return Error{err: ValidationError{RuleIndex: -1, Element: element, Err: err}}
+	}
+	if ve.Element != "" {
+		element += "." + ve.Element
+	}
+	ve.Element = element
+	return Error{err: ve}
+}
+
+// withRuleIndex annotates a validation error with the index
+// of the rule it was found in.
+func withRuleIndex(idx int, err error) error {
+	if err == nil {
+		return nil
+	}
+	var ve ValidationError
+	if !errors.As(err, &ve) {
+		ve = ValidationError{Err: err}
+	}
+	ve.RuleIndex = idx
+	return Error{err: ve}
+}
+
+// causeError keeps the message of err while exposing the underlying
+// cause, errors.Is matches err and errors.As the cause.
+type causeError struct {
+	err   error
+	cause error
+}
+
+func (e causeError) Error() string { return e.err.Error() }
+
+func (e causeError) Is(target error) bool { return target == e.err }
+
+func (e causeError) Unwrap() error { return e.cause }
+
+// withCause returns err annotated with the error which caused it.
+func withCause(err, cause error) error {
+	return Error{err: causeError{err: err, cause: cause}}
+}
+
+// warningError marks a validation finding which does not make the
+// configuration invalid but likely points to a mistake, see IsWarning.
+type warningError struct {
+	err error
+}
+
+func (e warningError) Error() string { return e.err.Error() }
+
+func (e warningError) Unwrap() error { return e.err }
+
+// asWarning returns err marked as a warning.
+func asWarning(err error) error {
+	return Error{err: warningError{err: err}}
+}
+
+// IsWarning returns true if err, as reported by ValidateAll, is only a
+// warning which does not fail Validate.
+func IsWarning(err error) bool {
+	var w warningError
+	return errors.As(err, &w)
+}
@@ -18,6 +18,8 @@ package lifecycle
 
 import (
 	"encoding/xml"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -33,17 +35,60 @@ var (
 type ExpirationDays int
 
 // UnmarshalXML parses number of days from Expiration and validates if
-// greater than zero
+// greater than zero, "Indefinite" leaves the days unset.
 func (eDays *ExpirationDays) UnmarshalXML(d *xml.Decoder, startElement xml.StartElement) error {
-	var numDays int
-	err := d.DecodeElement(&numDays, &startElement)
+	var daysStr string
+	err := d.DecodeElement(&daysStr, &startElement)
 	if err != nil {
 		return err
 	}
+	if isIndefiniteDays(daysStr) {
+		*eDays = 0
+		return nil
+	}
+	*eDays, err = parseExpirationDays(daysStr)
+	return err
+}
+
+// parseExpirationDays parses a number of days greater than zero.
+func parseExpirationDays(s string) (ExpirationDays, error) {
+	var (
+		numDays int
+		err     error
+	)
+	if s = strings.TrimSpace(s); s != "" {
+		if numDays, err = strconv.Atoi(s); err != nil {
+			return 0, err
+		}
+	}
 	if numDays <= 0 {
-		return errLifecycleInvalidDays
+		return 0, errLifecycleInvalidDays
 	}
-	*eDays = ExpirationDays(numDays)
+	return ExpirationDays(numDays), nil
+}
+
+// expirationDaysXML decodes Days in Expiration and records whether
+// it was given as "Indefinite".
+type expirationDaysXML struct {
+	days       ExpirationDays
+	indefinite bool
+}
+
+// UnmarshalXML parses Days like ExpirationDays.
+func (edx *expirationDaysXML) UnmarshalXML(d *xml.Decoder, startElement xml.StartElement) error {
+	var daysStr string
+	if err := d.DecodeElement(&daysStr, &startElement); err != nil {
+		return err
+	}
+	if isIndefiniteDays(daysStr) {
+		*edx = expirationDaysXML{indefinite: true}
+		return nil
+	}
+	days, err := parseExpirationDays(daysStr)
+	if err != nil {
+		return err
+	}
+	*edx = expirationDaysXML{days: days}
 	return nil
 }
 
@@ -77,14 +122,11 @@ func (eDate *ExpirationDate) UnmarshalXML(d *xml.Decoder, startElement xml.Start
 		return errLifecycleInvalidDate
 	}
 	// Allow only date timestamp specifying midnight GMT
-	hr, min, sec := expDate.Clock()
-	nsec := expDate.Nanosecond()
-	loc := expDate.Location()
-	if !(hr == 0 && min == 0 && sec == 0 && nsec == 0 && loc.String() == time.UTC.String()) {
+	if !isMidnightGMT(expDate) {
 		return errLifecycleDateNotMidnight
 	}
 
-	*eDate = ExpirationDate{expDate}
+	*eDate = ExpirationDate{expDate.UTC()}
 	return nil
 }
 
@@ -142,16 +184,32 @@ func (e Expiration) MarshalXML(enc *xml.Encoder, startElement xml.StartElement)
 	return enc.EncodeElement(expirationWrapper(e), startElement)
 }
 
-// UnmarshalXML decodes expiration field from the XML form.
+// UnmarshalXML decodes expiration field from the XML form. An
+// Expiration with nothing but "Indefinite" Days is left unset, the
+// object never expires.
 func (e *Expiration) UnmarshalXML(d *xml.Decoder, startElement xml.StartElement) error {
-	type expirationWrapper Expiration
+	type expirationWrapper struct {
+		XMLName      xml.Name           `xml:"Expiration"`
+		Days         expirationDaysXML  `xml:"Days"`
+		Date         ExpirationDate     `xml:"Date"`
+		DeleteMarker ExpireDeleteMarker `xml:"ExpiredObjectDeleteMarker"`
+	}
 	var exp expirationWrapper
 	err := d.DecodeElement(&exp, &startElement)
 	if err != nil {
 		return err
 	}
-	*e = Expiration(exp)
-	e.set = true
+	if exp.Days.indefinite && exp.Date.IsZero() && !exp.DeleteMarker.set {
+		*e = Expiration{}
+		return nil
+	}
+	*e = Expiration{
+		XMLName:      exp.XMLName,
+		Days:         exp.Days.days,
+		Date:         exp.Date,
+		DeleteMarker: exp.DeleteMarker,
+		set:          true,
+	}
 	return nil
 }
 
@@ -192,3 +250,39 @@ func (e Expiration) IsDateNull() bool {
 func (e Expiration) IsNull() bool {
 	return e.IsDaysNull() && e.IsDateNull()
 }
+
+// ResolveToDate returns a copy of the expiration pinned to the Date an
+// object last modified at modTime expires on, i.e. the midnight UTC
+// following modTime plus Days, with Days unset. Expirations without
+// Days are returned unchanged.
+func (e Expiration) ResolveToDate(modTime time.Time) Expiration {
+	if e.IsDaysNull() {
+		return e
+	}
+	resolved := e
+	resolved.Date = ExpirationDate{ExpectedExpiryTime(modTime, int(e.Days))}
+	resolved.Days = 0
+	return resolved
+}
+
+// NextActionTime returns the instant after which an object last modified
+// at modTime expires, either the expiration Date or the midnight after
+// modTime plus Days. It returns false if neither Days nor Date is set.
+func (e Expiration) NextActionTime(modTime time.Time) (time.Time, bool) {
+	switch {
+	case !e.IsDateNull():
+		return e.Date.Time, true
+	case !e.IsDaysNull():
+		return ExpectedExpiryTime(modTime, int(e.Days)), true
+	}
+	return time.Time{}, false
+}
+
+// IsExpirationImmediate returns true if an object last modified at modTime
+// is to be expired at now, i.e. now is past the expiration Date, or past
+// the midnight following modTime plus Days. It returns false if neither
+// Days nor Date is set.
+func (e Expiration) IsExpirationImmediate(modTime, now time.Time) bool {
+	at, ok := e.NextActionTime(modTime)
+	return ok && now.After(at)
+}
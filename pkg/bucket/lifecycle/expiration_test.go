@@ -17,9 +17,12 @@
 package lifecycle
 
 import (
+	"bytes"
 	"encoding/xml"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 )
 
 // appropriate errors on validation
@@ -110,3 +113,228 @@ func TestInvalidExpiration(t *testing.T) {
 		})
 	}
 }
+
+// TestExpirationValidate checks validation of each combination of
+// Days, Date and ExpiredObjectDeleteMarker in Expiration
+func TestExpirationValidate(t *testing.T) {
+	testCases := []struct {
+		inputXML    string
+		expectedErr error
+	}{
+		{ // Days only
+			inputXML:    `<Expiration><Days>3</Days></Expiration>`,
+			expectedErr: nil,
+		},
+		{ // Date only
+			inputXML:    `<Expiration><Date>2019-04-20T00:00:00Z</Date></Expiration>`,
+			expectedErr: nil,
+		},
+		{ // ExpiredObjectDeleteMarker only
+			inputXML:    `<Expiration><ExpiredObjectDeleteMarker>true</ExpiredObjectDeleteMarker></Expiration>`,
+			expectedErr: nil,
+		},
+		{ // Both Days and Date
+			inputXML:    `<Expiration><Days>3</Days><Date>2019-04-20T00:00:00Z</Date></Expiration>`,
+			expectedErr: errLifecycleInvalidExpiration,
+		},
+		{ // ExpiredObjectDeleteMarker with Days
+			inputXML:    `<Expiration><Days>3</Days><ExpiredObjectDeleteMarker>true</ExpiredObjectDeleteMarker></Expiration>`,
+			expectedErr: errLifecycleInvalidDeleteMarker,
+		},
+		{ // ExpiredObjectDeleteMarker with Date
+			inputXML:    `<Expiration><Date>2019-04-20T00:00:00Z</Date><ExpiredObjectDeleteMarker>false</ExpiredObjectDeleteMarker></Expiration>`,
+			expectedErr: errLifecycleInvalidDeleteMarker,
+		},
+		{ // Neither Days, Date nor ExpiredObjectDeleteMarker
+			inputXML:    `<Expiration></Expiration>`,
+			expectedErr: errXMLNotWellFormed,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var expiration Expiration
+			if err := xml.Unmarshal([]byte(tc.inputXML), &expiration); err != nil {
+				t.Fatal(err)
+			}
+			if err := expiration.Validate(); err != tc.expectedErr {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+// TestExpirationDaysDateExclusive checks that Expiration, like Transition,
+// requires exactly one of Days or Date when built in code
+func TestExpirationDaysDateExclusive(t *testing.T) {
+	date := ExpirationDate{time.Date(2019, time.April, 20, 0, 0, 0, 0, time.UTC)}
+	testCases := []struct {
+		expiration  Expiration
+		expectedErr error
+	}{
+		{
+			expiration:  Expiration{Days: ExpirationDays(3), set: true},
+			expectedErr: nil,
+		},
+		{
+			expiration:  Expiration{Date: date, set: true},
+			expectedErr: nil,
+		},
+		{
+			expiration:  Expiration{Days: ExpirationDays(3), Date: date, set: true},
+			expectedErr: errLifecycleInvalidExpiration,
+		},
+		{ // Neither, without ExpiredObjectDeleteMarker
+			expiration:  Expiration{set: true},
+			expectedErr: errXMLNotWellFormed,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if err := tc.expiration.Validate(); err != tc.expectedErr {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+		})
+	}
+
+	// Transition rejects the same combinations
+	both := Transition{Days: 3, Date: TransitionDate{Time: date.Time}, StorageClass: "GLACIER", daysSet: true, set: true}
+	if err := both.Validate(); err != errTransitionInvalid {
+		t.Fatalf("Expected %v but got %v", errTransitionInvalid, err)
+	}
+	neither := Transition{StorageClass: "GLACIER", set: true}
+	if err := neither.Validate(); err != errXMLNotWellFormed {
+		t.Fatalf("Expected %v but got %v", errXMLNotWellFormed, err)
+	}
+}
+
+// TestIndefiniteDays checks if the legacy "Indefinite" literal in Days
+// leaves the days unset in Expiration and Transition
+func TestIndefiniteDays(t *testing.T) {
+	for i, days := range []string{"Indefinite", "indefinite", " INDEFINITE "} {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var expiration Expiration
+			if err := xml.Unmarshal([]byte("<Expiration><Days>"+days+"</Days></Expiration>"), &expiration); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			if !expiration.IsDaysNull() || !expiration.IsNull() {
+				t.Fatalf("%d: Expected Days to be null but got %d", i+1, expiration.Days)
+			}
+			if expiration.set {
+				t.Fatalf("%d: Expected the expiration to be unset", i+1)
+			}
+
+			var transition Transition
+			if err := xml.Unmarshal([]byte("<Transition><Days>"+days+"</Days><StorageClass>WARM</StorageClass></Transition>"), &transition); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			if !transition.IsDaysNull() {
+				t.Fatalf("%d: Expected Days to be null but got %d", i+1, transition.Days)
+			}
+			b, err := xml.Marshal(transition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The transition is left unset
+			if len(b) != 0 {
+				t.Fatalf("%d: Expected nothing but got %s", i+1, string(b))
+			}
+		})
+	}
+
+	// Indefinite actions are dropped from a rule, which still validates
+	// and round-trips without them
+	inputConfig := `<LifecycleConfiguration>` +
+		`<Rule><ID>archive</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>Indefinite</Days></Expiration>` +
+		`<Transition><Days>30</Days><StorageClass>WARM</StorageClass></Transition></Rule>` +
+		`<Rule><ID>expire</ID><Status>Enabled</Status><Filter><Prefix>tmp/</Prefix></Filter><Expiration><Days>7</Days></Expiration>` +
+		`<Transition><Days>indefinite</Days><StorageClass>WARM</StorageClass></Transition></Rule>` +
+		`</LifecycleConfiguration>`
+	expectedConfig := `<LifecycleConfiguration>` +
+		`<Rule><ID>archive</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter>` +
+		`<Transition><Days>30</Days><StorageClass>WARM</StorageClass></Transition></Rule>` +
+		`<Rule><ID>expire</ID><Status>Enabled</Status><Filter><Prefix>tmp/</Prefix></Filter><Expiration><Days>7</Days></Expiration></Rule>` +
+		`</LifecycleConfiguration>`
+	lc, err := ParseLifecycleConfig(strings.NewReader(inputConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = lc.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	b, err := xml.Marshal(lc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expectedConfig {
+		t.Fatalf("Expected %s but got %s", expectedConfig, string(b))
+	}
+	reparsed, err := ParseLifecycleConfig(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = reparsed.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	var expiration Expiration
+	if err := xml.Unmarshal([]byte("<Expiration><Days>Forever</Days></Expiration>"), &expiration); err == nil {
+		t.Fatal("Expected an error for a non numeric Days")
+	}
+}
+
+func TestExpirationIsExpirationImmediate(t *testing.T) {
+	date := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	modTime := time.Date(2020, time.May, 21, 13, 42, 50, 0, time.UTC)
+	// Expected expiry of modTime with 3 days
+	expiry := time.Date(2020, time.May, 25, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		expiration Expiration
+		now        time.Time
+		expected   bool
+	}{
+		{ // Before Date
+			expiration: Expiration{Date: ExpirationDate{date}, set: true},
+			now:        date.Add(-time.Second),
+			expected:   false,
+		},
+		{ // Exactly at Date
+			expiration: Expiration{Date: ExpirationDate{date}, set: true},
+			now:        date,
+			expected:   false,
+		},
+		{ // Just past Date
+			expiration: Expiration{Date: ExpirationDate{date}, set: true},
+			now:        date.Add(time.Nanosecond),
+			expected:   true,
+		},
+		{ // A past midnight Date
+			expiration: Expiration{Date: ExpirationDate{date}, set: true},
+			now:        date.Add(365 * 24 * time.Hour),
+			expected:   true,
+		},
+		{ // Days, exactly at the expected expiry
+			expiration: Expiration{Days: 3, set: true},
+			now:        expiry,
+			expected:   false,
+		},
+		{ // Days, just past the expected expiry
+			expiration: Expiration{Days: 3, set: true},
+			now:        expiry.Add(time.Nanosecond),
+			expected:   true,
+		},
+		{ // Neither Days nor Date
+			expiration: Expiration{DeleteMarker: ExpireDeleteMarker{val: true, set: true}, set: true},
+			now:        date,
+			expected:   false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if got := tc.expiration.IsExpirationImmediate(modTime, tc.now); got != tc.expected {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected, got)
+			}
+		})
+	}
+}
@@ -17,12 +17,21 @@
 package lifecycle
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
+	"fmt"
 	"io"
+	"sort"
+	"strings"
 )
 
 var (
 	errInvalidFilter = Errorf("Filter must have exactly one of Prefix, Tag, or And specified")
+
+	errInvalidFilterObjectSize = Errorf("ObjectSizeGreaterThan and ObjectSizeLessThan can only be combined with other predicates inside And")
+	errInvalidObjectSize       = Errorf("Object size in Filter must be a non-negative integer")
+	errInvalidObjectSizeRange  = Errorf("ObjectSizeGreaterThan must be less than ObjectSizeLessThan")
 )
 
 // Filter - a filter for a lifecycle configuration Rule.
@@ -36,6 +45,14 @@ type Filter struct {
 
 	Tag    Tag
 	tagSet bool
+
+	ObjectSizeGreaterThan int64
+	ObjectSizeLessThan    int64
+
+	// set is true if the Filter element was given, an empty
+	// <Filter></Filter> applies to all objects.
+	set bool
+
 	// Caching tags, only once
 	cachedTags []string
 }
@@ -56,6 +73,16 @@ func (f Filter) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		if err := e.EncodeElement(f.Tag, xml.StartElement{Name: xml.Name{Local: "Tag"}}); err != nil {
 			return err
 		}
+	case f.ObjectSizeGreaterThan > 0:
+		if err := e.EncodeElement(f.ObjectSizeGreaterThan, xml.StartElement{Name: xml.Name{Local: "ObjectSizeGreaterThan"}}); err != nil {
+			return err
+		}
+	case f.ObjectSizeLessThan > 0:
+		if err := e.EncodeElement(f.ObjectSizeLessThan, xml.StartElement{Name: xml.Name{Local: "ObjectSizeLessThan"}}); err != nil {
+			return err
+		}
+	case f.set && !f.Prefix.set:
+		// An empty Filter, matching all objects, is kept empty
 	default:
 		// Always print Prefix field when both And & Tag are empty
 		if err := e.EncodeElement(f.Prefix, xml.StartElement{Name: xml.Name{Local: "Prefix"}}); err != nil {
@@ -68,6 +95,7 @@ func (f Filter) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 
 // UnmarshalXML - decodes XML data.
 func (f *Filter) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
+	f.set = true
 	for {
 		// Read tokens from the XML document in a stream.
 		t, err := d.Token()
@@ -101,6 +129,14 @@ func (f *Filter) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error
 				}
 				f.Tag = tag
 				f.tagSet = true
+			case "ObjectSizeGreaterThan":
+				if err = d.DecodeElement(&f.ObjectSizeGreaterThan, &se); err != nil {
+					return err
+				}
+			case "ObjectSizeLessThan":
+				if err = d.DecodeElement(&f.ObjectSizeLessThan, &se); err != nil {
+					return err
+				}
 			default:
 				return errUnknownXMLTag
 			}
@@ -111,14 +147,42 @@ func (f *Filter) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error
 
 // IsEmpty returns true if Filter is not specified in the XML
 func (f Filter) IsEmpty() bool {
-	return !f.Prefix.set && !f.andSet && !f.tagSet
+	return !f.Prefix.set && !f.andSet && !f.tagSet && !f.sizeSet()
 }
 
-// Validate - validates the filter element
+// sizeSet returns true if any object size bound is specified
+func (f Filter) sizeSet() bool {
+	return f.ObjectSizeGreaterThan != 0 || f.ObjectSizeLessThan != 0
+}
+
+// IsMatchAll returns true if the Filter element was given without any
+// predicate, i.e. <Filter></Filter>, which applies to all objects.
+func (f Filter) IsMatchAll() bool {
+	return f.set && f.IsEmpty()
+}
+
+// Validate - validates the filter element, an empty Filter
+// element is valid and applies to all objects.
 func (f Filter) Validate() error {
-	if !f.Prefix.set && !f.andSet && !f.tagSet {
+	if f.IsMatchAll() {
+		return nil
+	}
+	if f.IsEmpty() {
 		return errXMLNotWellFormed
 	}
+	// Object size bounds can be combined with other predicates
+	// only inside And.
+	if f.sizeSet() {
+		if f.Prefix.set || !f.Tag.IsEmpty() || !f.And.isEmpty() {
+			return errInvalidFilterObjectSize
+		}
+		if f.ObjectSizeGreaterThan != 0 && f.ObjectSizeLessThan != 0 {
+			return errInvalidFilterObjectSize
+		}
+		if f.ObjectSizeGreaterThan < 0 || f.ObjectSizeLessThan < 0 {
+			return errInvalidObjectSize
+		}
+	}
 	// A Filter must have exactly one of Prefix, Tag, or And specified.
 	if !f.And.isEmpty() {
 		if f.Prefix.set {
@@ -173,3 +237,83 @@ func (f Filter) TestTags(tags []string) bool {
 	}
 	return true
 }
+
+// Match returns true if an object with the given name, size and tags
+// satisfies every predicate of the Filter. Every tag of the Filter,
+// including all the tags of And, must be present in tags with the
+// same value.
+func (f Filter) Match(objName string, size int64, tags map[string]string) bool {
+	if !strings.HasPrefix(objName, f.Prefix.String()) || !strings.HasPrefix(objName, f.And.Prefix.String()) {
+		return false
+	}
+	filterTags := make([]Tag, 0, len(f.And.Tags)+1)
+	filterTags = append(filterTags, f.Tag)
+	filterTags = append(filterTags, f.And.Tags...)
+	for _, t := range filterTags {
+		if t.IsEmpty() {
+			continue
+		}
+		if v, ok := tags[t.Key]; !ok || v != t.Value {
+			return false
+		}
+	}
+	return f.TestSize(size)
+}
+
+// Equals returns true if both filters select the same objects, whether
+// their predicates are given directly or inside And, and regardless of
+// the order of the tags.
+func (f Filter) Equals(other Filter) bool {
+	return f.canonical() == other.canonical()
+}
+
+// Hash returns the hex encoded SHA-256 of the predicates of the
+// filter, filters which are Equals have the same Hash.
+func (f Filter) Hash() string {
+	sum := sha256.Sum256([]byte(f.canonical()))
+	return hex.EncodeToString(sum[:])
+}
+
+// canonical returns a string identifying the predicates of the filter,
+// with the tags sorted and the object size bounds combined.
+func (f Filter) canonical() string {
+	prefix := f.Prefix.String()
+	if p := f.And.Prefix.String(); p != "" {
+		prefix = p
+	}
+	var tags []string
+	for _, t := range append([]Tag{f.Tag}, f.And.Tags...) {
+		if !t.IsEmpty() {
+			tags = append(tags, t.String())
+		}
+	}
+	sort.Strings(tags)
+	greaterThan := f.ObjectSizeGreaterThan
+	if f.And.ObjectSizeGreaterThan > greaterThan {
+		greaterThan = f.And.ObjectSizeGreaterThan
+	}
+	lessThan := f.ObjectSizeLessThan
+	if lt := f.And.ObjectSizeLessThan; lt > 0 && (lessThan == 0 || lt < lessThan) {
+		lessThan = lt
+	}
+	return fmt.Sprintf("%q %q %d %d", prefix, tags, greaterThan, lessThan)
+}
+
+// TestSize tests if the object size satisfies the Filter size bounds,
+// it returns true if there are no size bounds in the underlying Filter.
+func (f Filter) TestSize(size int64) bool {
+	return matchSize(size, f.ObjectSizeGreaterThan, f.ObjectSizeLessThan) &&
+		matchSize(size, f.And.ObjectSizeGreaterThan, f.And.ObjectSizeLessThan)
+}
+
+// matchSize returns true if size is within the given bounds,
+// a zero bound is not enforced.
+func matchSize(size, greaterThan, lessThan int64) bool {
+	if greaterThan > 0 && size <= greaterThan {
+		return false
+	}
+	if lessThan > 0 && size >= lessThan {
+		return false
+	}
+	return true
+}
@@ -19,6 +19,7 @@ package lifecycle
 import (
 	"encoding/xml"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -98,6 +99,21 @@ func TestUnsupportedFilters(t *testing.T) {
 						</Filter>`,
 			expectedErr: nil,
 		},
+		{ // Filter with And and multiple Tag tags, without Prefix
+			inputXML: ` <Filter>
+							<And>
+							<Tag>
+								<Key>key1</Key>
+								<Value>value1</Value>
+							</Tag>
+							<Tag>
+								<Key>key2</Key>
+								<Value>value2</Value>
+							</Tag>
+							</And>
+						</Filter>`,
+			expectedErr: nil,
+		},
 		{ // Filter without And and single Tag tag
 			inputXML: ` <Filter>
 							<Prefix>key-prefix</Prefix>
@@ -123,3 +139,330 @@ func TestUnsupportedFilters(t *testing.T) {
 		})
 	}
 }
+
+// TestFilterMarshal checks if each supported shape of Filter is
+// marshaled back to the same XML it was parsed from
+func TestFilterMarshal(t *testing.T) {
+	testCases := []string{
+		`<Filter><Prefix>key-prefix</Prefix></Filter>`,
+		`<Filter><Tag><Key>key1</Key><Value>value1</Value></Tag></Filter>`,
+		`<Filter><And><Prefix>key-prefix</Prefix><Tag><Key>key1</Key><Value>value1</Value></Tag><Tag><Key>key2</Key><Value>value2</Value></Tag></And></Filter>`,
+		`<Filter><And><Tag><Key>key1</Key><Value>value1</Value></Tag><Tag><Key>key2</Key><Value>value2</Value></Tag></And></Filter>`,
+	}
+	for i, inputXML := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var filter Filter
+			if err := xml.Unmarshal([]byte(inputXML), &filter); err != nil {
+				t.Fatal(err)
+			}
+			if err := filter.Validate(); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			b, err := xml.Marshal(filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != inputXML {
+				t.Fatalf("%d: Expected %s but got %s", i+1, inputXML, string(b))
+			}
+		})
+	}
+}
+
+// TestFilterObjectSize checks validation of object size bounds in Filter
+func TestFilterObjectSize(t *testing.T) {
+	testCases := []struct {
+		inputXML    string
+		expectedErr error
+	}{
+		{ // Single size bound
+			inputXML:    `<Filter><ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan></Filter>`,
+			expectedErr: nil,
+		},
+		{ // Size bound combined with a prefix outside And
+			inputXML:    `<Filter><Prefix>key-prefix</Prefix><ObjectSizeLessThan>1024</ObjectSizeLessThan></Filter>`,
+			expectedErr: errInvalidFilterObjectSize,
+		},
+		{ // Size range in And
+			inputXML:    `<Filter><And><ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan><ObjectSizeLessThan>4096</ObjectSizeLessThan></And></Filter>`,
+			expectedErr: nil,
+		},
+		{ // Size bound with a prefix in And
+			inputXML:    `<Filter><And><Prefix>key-prefix</Prefix><ObjectSizeLessThan>4096</ObjectSizeLessThan></And></Filter>`,
+			expectedErr: nil,
+		},
+		{ // Empty size range in And
+			inputXML:    `<Filter><And><ObjectSizeGreaterThan>4096</ObjectSizeGreaterThan><ObjectSizeLessThan>4096</ObjectSizeLessThan></And></Filter>`,
+			expectedErr: errInvalidObjectSizeRange,
+		},
+		{ // Inverted size range in And
+			inputXML:    `<Filter><And><ObjectSizeGreaterThan>4096</ObjectSizeGreaterThan><ObjectSizeLessThan>1024</ObjectSizeLessThan></And></Filter>`,
+			expectedErr: errInvalidObjectSizeRange,
+		},
+		{ // Negative size bound
+			inputXML:    `<Filter><ObjectSizeLessThan>-1</ObjectSizeLessThan></Filter>`,
+			expectedErr: errInvalidObjectSize,
+		},
+		{ // Size bound together with multiple tags
+			inputXML:    `<Filter><And><ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan><Tag><Key>key1</Key><Value>value1</Value></Tag><Tag><Key>key2</Key><Value>value2</Value></Tag></And></Filter>`,
+			expectedErr: nil,
+		},
+		{ // Size bounds together with a tag
+			inputXML:    `<Filter><And><ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan><ObjectSizeLessThan>4096</ObjectSizeLessThan><Tag><Key>key1</Key><Value>value1</Value></Tag></And></Filter>`,
+			expectedErr: nil,
+		},
+		{ // Tags with a size bound are still checked for duplicates
+			inputXML:    `<Filter><And><ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan><Tag><Key>key1</Key><Value>value1</Value></Tag><Tag><Key>key1</Key><Value>value2</Value></Tag></And></Filter>`,
+			expectedErr: errDuplicateTagKey,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var filter Filter
+			if err := xml.Unmarshal([]byte(tc.inputXML), &filter); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			if err := filter.Validate(); err != tc.expectedErr {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	sizeRange := `<Filter><And><Prefix>data/</Prefix><ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan><ObjectSizeLessThan>4096</ObjectSizeLessThan></And></Filter>`
+	sizeAndTags := `<Filter><And><ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan><Tag><Key>key1</Key><Value>value1</Value></Tag><Tag><Key>key2</Key><Value>value2</Value></Tag></And></Filter>`
+	andTags := `<Filter><And><Tag><Key>key1</Key><Value>value1</Value></Tag><Tag><Key>key2</Key><Value>value2</Value></Tag></And></Filter>`
+	testCases := []struct {
+		inputXML      string
+		objName       string
+		size          int64
+		tags          map[string]string
+		expectedMatch bool
+	}{
+		{
+			inputXML:      sizeRange,
+			objName:       "data/obj",
+			size:          512,
+			expectedMatch: false,
+		},
+		{
+			inputXML:      sizeRange,
+			objName:       "data/obj",
+			size:          2048,
+			expectedMatch: true,
+		},
+		{
+			inputXML:      sizeRange,
+			objName:       "data/obj",
+			size:          8192,
+			expectedMatch: false,
+		},
+		{ // Bounds are exclusive
+			inputXML:      sizeRange,
+			objName:       "data/obj",
+			size:          1024,
+			expectedMatch: false,
+		},
+		{
+			inputXML:      sizeRange,
+			objName:       "other/obj",
+			size:          2048,
+			expectedMatch: false,
+		},
+		{
+			inputXML:      `<Filter><ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan></Filter>`,
+			objName:       "obj",
+			size:          2048,
+			expectedMatch: true,
+		},
+		{
+			inputXML:      `<Filter><Tag><Key>key1</Key><Value>value1</Value></Tag></Filter>`,
+			objName:       "obj",
+			tags:          map[string]string{"key1": "value1"},
+			expectedMatch: true,
+		},
+		{
+			inputXML:      `<Filter><Tag><Key>key1</Key><Value>value1</Value></Tag></Filter>`,
+			objName:       "obj",
+			tags:          map[string]string{"key1": "value2"},
+			expectedMatch: false,
+		},
+		{ // Tags match but the size bound fails
+			inputXML:      sizeAndTags,
+			objName:       "obj",
+			size:          512,
+			tags:          map[string]string{"key1": "value1", "key2": "value2"},
+			expectedMatch: false,
+		},
+		{ // Size bound holds but a tag is missing
+			inputXML:      sizeAndTags,
+			objName:       "obj",
+			size:          2048,
+			tags:          map[string]string{"key1": "value1"},
+			expectedMatch: false,
+		},
+		{ // Size bound holds but a tag differs
+			inputXML:      sizeAndTags,
+			objName:       "obj",
+			size:          2048,
+			tags:          map[string]string{"key1": "value1", "key2": "other"},
+			expectedMatch: false,
+		},
+		{
+			inputXML:      sizeAndTags,
+			objName:       "obj",
+			size:          2048,
+			tags:          map[string]string{"key1": "value1", "key2": "value2", "key3": "value3"},
+			expectedMatch: true,
+		},
+		{ // Every tag of And must be present
+			inputXML:      andTags,
+			objName:       "obj",
+			tags:          map[string]string{"key2": "value2"},
+			expectedMatch: false,
+		},
+		{
+			inputXML:      andTags,
+			objName:       "obj",
+			tags:          map[string]string{"key1": "value1", "key2": "value2"},
+			expectedMatch: true,
+		},
+		{ // Tag values are case sensitive
+			inputXML:      andTags,
+			objName:       "obj",
+			tags:          map[string]string{"key1": "value1", "key2": "VALUE2"},
+			expectedMatch: false,
+		},
+		{
+			inputXML:      andTags,
+			objName:       "obj",
+			expectedMatch: false,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var filter Filter
+			if err := xml.Unmarshal([]byte(tc.inputXML), &filter); err != nil {
+				t.Fatal(err)
+			}
+			if got := filter.Match(tc.objName, tc.size, tc.tags); got != tc.expectedMatch {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedMatch, got)
+			}
+		})
+	}
+}
+
+func TestFilterEquals(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{
+			a:        `<Filter><Prefix>logs/</Prefix></Filter>`,
+			b:        `<Filter><Prefix>logs/</Prefix></Filter>`,
+			expected: true,
+		},
+		{
+			a:        `<Filter><Prefix>logs/</Prefix></Filter>`,
+			b:        `<Filter><Prefix>tmp/</Prefix></Filter>`,
+			expected: false,
+		},
+		{ // Tag order doesn't matter
+			a:        `<Filter><And><Prefix>logs/</Prefix><Tag><Key>key1</Key><Value>value1</Value></Tag><Tag><Key>key2</Key><Value>value2</Value></Tag></And></Filter>`,
+			b:        `<Filter><And><Tag><Key>key2</Key><Value>value2</Value></Tag><Tag><Key>key1</Key><Value>value1</Value></Tag><Prefix>logs/</Prefix></And></Filter>`,
+			expected: true,
+		},
+		{
+			a:        `<Filter><And><Prefix>logs/</Prefix><Tag><Key>key1</Key><Value>value1</Value></Tag><Tag><Key>key2</Key><Value>value2</Value></Tag></And></Filter>`,
+			b:        `<Filter><And><Prefix>logs/</Prefix><Tag><Key>key1</Key><Value>value1</Value></Tag><Tag><Key>key2</Key><Value>other</Value></Tag></And></Filter>`,
+			expected: false,
+		},
+		{ // A single tag, directly or in And
+			a:        `<Filter><Tag><Key>key1</Key><Value>value1</Value></Tag></Filter>`,
+			b:        `<Filter><And><Tag><Key>key1</Key><Value>value1</Value></Tag><ObjectSizeGreaterThan>0</ObjectSizeGreaterThan></And></Filter>`,
+			expected: true,
+		},
+		{ // An empty prefix matches all objects, as no filter
+			a:        `<Filter><Prefix></Prefix></Filter>`,
+			b:        `<Filter></Filter>`,
+			expected: true,
+		},
+		{
+			a:        `<Filter><ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan></Filter>`,
+			b:        `<Filter><ObjectSizeLessThan>1024</ObjectSizeLessThan></Filter>`,
+			expected: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var a, b Filter
+			if err := xml.Unmarshal([]byte(tc.a), &a); err != nil {
+				t.Fatal(err)
+			}
+			if err := xml.Unmarshal([]byte(tc.b), &b); err != nil {
+				t.Fatal(err)
+			}
+			if got := a.Equals(b); got != tc.expected {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected, got)
+			}
+			if got := b.Equals(a); got != tc.expected {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected, got)
+			}
+			if got := a.Hash() == b.Hash(); got != tc.expected {
+				t.Fatalf("%d: Expected equal hashes to be %v but got %v", i+1, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestEmptyFilterMatchesAll(t *testing.T) {
+	var filter Filter
+	if err := xml.Unmarshal([]byte(`<Filter></Filter>`), &filter); err != nil {
+		t.Fatal(err)
+	}
+	if !filter.IsMatchAll() {
+		t.Fatal("Expected an empty Filter to match all objects")
+	}
+	if err := filter.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	for i, obj := range []struct {
+		name string
+		size int64
+		tags map[string]string
+	}{
+		{name: "obj"},
+		{name: "dir/obj", size: 1 << 20},
+		{name: "obj", tags: map[string]string{"key1": "value1"}},
+	} {
+		if !filter.Match(obj.name, obj.size, obj.tags) {
+			t.Fatalf("%d: Expected %s to match", i+1, obj.name)
+		}
+	}
+	b, err := xml.Marshal(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `<Filter></Filter>` {
+		t.Fatalf("Expected <Filter></Filter> but got %s", b)
+	}
+
+	// A Filter built without any predicate is still invalid
+	if err = (Filter{}).Validate(); err != errXMLNotWellFormed {
+		t.Fatalf("Expected %v but got %v", errXMLNotWellFormed, err)
+	}
+
+	// A rule with an empty Filter applies to every object
+	lc, err := ParseLifecycleConfig(strings.NewReader(`<LifecycleConfiguration><Rule><ID>all</ID><Status>Enabled</Status><Filter></Filter><Expiration><Days>1</Days></Expiration></Rule></LifecycleConfiguration>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = lc.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if rules := lc.FilterRules("any/obj", nil); len(rules) != 1 {
+		t.Fatalf("Expected the rule to match but got %v", rules)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * MinIO Cloud Storage, (C) 2021 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lifecycle
+
+import (
+	"testing"
+)
+
+// FuzzParseLifecycleConfig feeds arbitrary input to the parser, see
+// checkParseLifecycleConfig. Seeds live under
+// testdata/fuzz/FuzzParseLifecycleConfig and are also replayed by
+// TestParseLifecycleConfigSeedCorpus on toolchains without fuzzing.
+func FuzzParseLifecycleConfig(f *testing.F) {
+	f.Add([]byte(`<LifecycleConfiguration><Rule><ID>rule</ID><Filter><Prefix>prefix/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>3</Days></Expiration></Rule></LifecycleConfiguration>`))
+	f.Add([]byte(`<LifecycleConfiguration><Rule>`))
+
+	f.Fuzz(checkParseLifecycleConfig)
+}
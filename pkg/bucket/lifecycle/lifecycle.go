@@ -17,17 +17,35 @@
 package lifecycle
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"encoding/xml"
+	"fmt"
 	"io"
+	"math"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var (
 	errLifecycleTooManyRules = Errorf("Lifecycle configuration allows a maximum of 1000 rules")
-	errLifecycleNoRule       = Errorf("Lifecycle configuration should have at least one rule")
 	errLifecycleDuplicateID  = Errorf("Lifecycle configuration has rule with the same ID. Rule ID must be unique.")
 	errXMLNotWellFormed      = Errorf("The XML you provided was not well-formed or did not validate against our published schema")
+
+	errLifecycleConflictingRules  = Errorf("Lifecycle configuration has overlapping rules with conflicting actions")
+	errLifecycleTooDeeplyNested   = Errorf("Lifecycle configuration XML is nested too deeply")
+	errLifecycleRuleMissingID     = Errorf("Rule ID must not be empty")
+	errLifecycleDisabledRuleMatch = Errorf("Disabled rule would apply to the object")
+	errLifecycleRuleNotFound      = Errorf("Lifecycle configuration has no rule with the given ID")
+	errLifecycleConfigTooLarge    = Errorf("Lifecycle configuration is too large")
+	errLifecycleReservedPrefix    = Errorf("Rule could apply to objects under a reserved prefix")
 )
 
 const (
@@ -60,7 +78,25 @@ const (
 	DeleteRestoredVersionAction
 )
 
-// Lifecycle - Configuration for bucket lifecycle.
+// DefaultMaxRules is the number of rules a lifecycle configuration
+// may have at most, as enforced by AWS S3.
+const DefaultMaxRules = 1000
+
+// tooManyRulesError reports a configuration exceeding a rule
+// limit other than DefaultMaxRules.
+type tooManyRulesError struct {
+	maxRules int
+}
+
+func (e tooManyRulesError) Error() string {
+	return fmt.Sprintf("Lifecycle configuration allows a maximum of %d rules", e.maxRules)
+}
+
+func (e tooManyRulesError) Is(target error) bool { return target == errLifecycleTooManyRules }
+
+// Lifecycle - Configuration for bucket lifecycle. Rules are kept in the
+// order they are parsed in and marshaled back in that same order, only
+// Sort reorders them.
 type Lifecycle struct {
 	XMLName xml.Name `xml:"LifecycleConfiguration"`
 	Rules   []Rule   `xml:"Rule"`
@@ -71,6 +107,17 @@ type Lifecycle struct {
 // If recursive is specified the function will also return true if any level below the
 // prefix has active rules. If no prefix is specified recursive is effectively true.
 func (lc Lifecycle) HasActiveRules(prefix string, recursive bool) bool {
+	return lc.hasActiveRules(prefix, recursive, true)
+}
+
+// HasActiveObjectRules is like HasActiveRules but leaves out rules which
+// only abort incomplete multipart uploads, as they take no action on
+// objects and give the scanner nothing to do.
+func (lc Lifecycle) HasActiveObjectRules(prefix string, recursive bool) bool {
+	return lc.hasActiveRules(prefix, recursive, false)
+}
+
+func (lc Lifecycle) hasActiveRules(prefix string, recursive, withAbort bool) bool {
 	if len(lc.Rules) == 0 {
 		return false
 	}
@@ -100,62 +147,843 @@ func (lc Lifecycle) HasActiveRules(prefix string, recursive bool) bool {
 		if rule.NoncurrentVersionTransition.NoncurrentDays > 0 {
 			return true
 		}
-		if rule.Expiration.IsNull() && rule.Transition.IsNull() {
+		if withAbort && rule.AbortIncompleteMultipartUpload.DaysAfterInitiation > 0 {
+			return true
+		}
+		if rule.Expiration.IsNull() && rule.isTransitionNull() {
 			continue
 		}
 		if !rule.Expiration.IsDateNull() && rule.Expiration.Date.Before(time.Now()) {
 			return true
 		}
-		if !rule.Transition.IsDateNull() && rule.Transition.Date.Before(time.Now()) {
+		if !rule.Expiration.IsDaysNull() {
 			return true
 		}
-		if !rule.Expiration.IsDaysNull() || !rule.Transition.IsDaysNull() {
-			return true
+		for _, transition := range rule.Transitions {
+			if !transition.IsDateNull() && transition.Date.Before(time.Now()) {
+				return true
+			}
+			if !transition.IsDaysNull() {
+				return true
+			}
 		}
 	}
 	return false
 }
 
+// String returns a human readable dump of all the rules, one per line.
+func (lc Lifecycle) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Lifecycle(%d rules)", len(lc.Rules))
+	for _, rule := range lc.Rules {
+		sb.WriteString("\n  ")
+		sb.WriteString(rule.String())
+	}
+	return sb.String()
+}
+
+// maxXMLDepth is the deepest element nesting accepted while parsing a
+// lifecycle configuration. The deepest valid path,
+// LifecycleConfiguration/Rule/Filter/And/Tag/Key, is far below it.
+const maxXMLDepth = 32
+
+// depthLimitedReader is an xml.TokenReader failing once elements are
+// nested deeper than maxXMLDepth, bounding the work done on hostile input.
+type depthLimitedReader struct {
+	d     *xml.Decoder
+	depth int
+}
+
+func (r *depthLimitedReader) Token() (xml.Token, error) {
+	tok, err := r.d.Token()
+	if err != nil {
+		return tok, err
+	}
+	switch tok.(type) {
+	case xml.StartElement:
+		r.depth++
+		if r.depth > maxXMLDepth {
+			return nil, errLifecycleTooDeeplyNested
+		}
+	case xml.EndElement:
+		r.depth--
+	}
+	return tok, nil
+}
+
+// Sort orders the rules of lc by ID, rules without an ID last. Rules
+// sharing an ID keep their relative order. It is meant to present
+// configurations in a reproducible order, e.g. before diffing them.
+func (lc *Lifecycle) Sort() {
+	sort.SliceStable(lc.Rules, func(i, j int) bool {
+		a, b := lc.Rules[i].ID, lc.Rules[j].ID
+		if a == "" || b == "" {
+			return b == "" && a != ""
+		}
+		return a < b
+	})
+}
+
+// StorageClasses returns the storage classes referenced by the
+// Transitions and NoncurrentVersionTransition of all rules, without
+// duplicates and sorted.
+func (lc Lifecycle) StorageClasses() []string {
+	seen := make(map[string]struct{})
+	var classes []string
+	add := func(sc string) {
+		if _, ok := seen[sc]; ok || sc == "" {
+			return
+		}
+		seen[sc] = struct{}{}
+		classes = append(classes, sc)
+	}
+	for _, r := range lc.Rules {
+		for _, t := range r.Transitions {
+			add(t.StorageClass)
+		}
+		add(r.NoncurrentVersionTransition.StorageClass)
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// Hash returns a hex encoded SHA-256 digest of the configuration, meant
+// for change detection. It is computed over the rules sorted by ID, with
+// upper-cased storage classes, And tags sorted by key and dates in RFC 3339,
+// so configurations differing only in the ordering of their elements or in
+// the layout of a date hash identically.
+func (lc Lifecycle) Hash() string {
+	type encodedRule struct {
+		id  string
+		xml []byte
+	}
+	encoded := make([]encodedRule, 0, len(lc.Rules))
+	for _, r := range lc.Rules {
+		encoded = append(encoded, encodedRule{id: r.ID, xml: canonicalRuleXML(r)})
+	}
+	// Rules without an ID or sharing one are ordered by their encoding.
+	sort.Slice(encoded, func(i, j int) bool {
+		if encoded[i].id != encoded[j].id {
+			return encoded[i].id < encoded[j].id
+		}
+		return bytes.Compare(encoded[i].xml, encoded[j].xml) < 0
+	})
+	h := sha256.New()
+	for _, r := range encoded {
+		h.Write(r.xml)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalRuleXML returns the XML encoding of the rule with upper-cased
+// storage classes, And tags sorted by key and dates in RFC 3339.
+func canonicalRuleXML(r Rule) []byte {
+	r = r.Clone()
+	sort.Slice(r.Filter.And.Tags, func(i, j int) bool {
+		return r.Filter.And.Tags[i].Key < r.Filter.And.Tags[j].Key
+	})
+	for i := range r.Transitions {
+		r.Transitions[i].StorageClass = strings.ToUpper(r.Transitions[i].StorageClass)
+		r.Transitions[i].Date.layout = ""
+	}
+	r.NoncurrentVersionTransition.StorageClass = strings.ToUpper(r.NoncurrentVersionTransition.StorageClass)
+	// Rules hold no type xml can't encode, marshaling can't fail.
+	b, _ := xml.Marshal(r)
+	return b
+}
+
+// FillMissingIDs assigns an ID to every rule of lc without one and
+// returns the number of IDs assigned. IDs are derived from the content
+// of the rule, e.g. rule-1f2e3d4c5b6a7988, so the same configuration
+// always gets the same IDs. Identical rules get a "-N" suffix, N
+// counting from 1, as in Merge.
+func (lc *Lifecycle) FillMissingIDs() int {
+	filled := 0
+	for i := range lc.Rules {
+		if lc.Rules[i].ID != "" {
+			continue
+		}
+		sum := sha256.Sum256(canonicalRuleXML(lc.Rules[i]))
+		base := "rule-" + hex.EncodeToString(sum[:8])
+		id := base
+		for n := 1; hasRuleID(lc.Rules, id); n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		lc.Rules[i].ID = id
+		filled++
+	}
+	return filled
+}
+
 // ParseLifecycleConfig - parses data in given reader to Lifecycle.
 func ParseLifecycleConfig(reader io.Reader) (*Lifecycle, error) {
 	var lc Lifecycle
-	if err := xml.NewDecoder(reader).Decode(&lc); err != nil {
+	d := xml.NewTokenDecoder(&depthLimitedReader{d: xml.NewDecoder(reader)})
+	if err := d.Decode(&lc); err != nil {
 		return nil, err
 	}
 	return &lc, nil
 }
 
-// Validate - validates the lifecycle configuration
+// ParseLifecycleConfigLimited is like ParseLifecycleConfig but reads at
+// most maxBytes from reader, failing if the configuration is larger.
+func ParseLifecycleConfigLimited(reader io.Reader, maxBytes int64) (Lifecycle, error) {
+	if maxBytes == math.MaxInt64 {
+		// No configuration can exceed it, and maxBytes+1 would overflow.
+		lc, err := ParseLifecycleConfig(reader)
+		if err != nil {
+			return Lifecycle{}, err
+		}
+		return *lc, nil
+	}
+	lr := io.LimitReader(reader, maxBytes+1).(*io.LimitedReader)
+	lc, err := ParseLifecycleConfig(lr)
+	if lr.N <= 0 {
+		return Lifecycle{}, Errorf("%w, it exceeds %d bytes", errLifecycleConfigTooLarge, maxBytes)
+	}
+	if err != nil {
+		return Lifecycle{}, err
+	}
+	return *lc, nil
+}
+
+// ParseLifecycleConfigFlexible is like ParseLifecycleConfig but accepts
+// any name for the root element, e.g. <BucketLifecycle>, as long as the
+// rules are its <Rule> children.
+func ParseLifecycleConfigFlexible(reader io.Reader) (*Lifecycle, error) {
+	var config struct {
+		XMLName xml.Name
+		Rules   []Rule `xml:"Rule"`
+	}
+	d := xml.NewTokenDecoder(&depthLimitedReader{d: xml.NewDecoder(reader)})
+	if err := d.Decode(&config); err != nil {
+		return nil, err
+	}
+	return &Lifecycle{Rules: config.Rules}, nil
+}
+
+// ParseLifecycleConfigLenient is like ParseLifecycleConfig but defaults
+// the Status of rules which have none to Enabled, for importing
+// configurations from clients which omit it. ParseLifecycleConfig keeps
+// the empty Status, which fails Validate.
+func ParseLifecycleConfigLenient(reader io.Reader) (*Lifecycle, error) {
+	lc, err := ParseLifecycleConfig(reader)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lc.Rules {
+		if lc.Rules[i].Status == "" {
+			lc.Rules[i].Status = Enabled
+		}
+	}
+	return lc, nil
+}
+
+// WarningCollector collects warnings about deprecated elements found
+// while parsing a configuration, which is otherwise silently normalized.
+type WarningCollector struct {
+	Warnings []string
+}
+
+func (w *WarningCollector) add(format string, args ...interface{}) {
+	w.Warnings = append(w.Warnings, fmt.Sprintf(format, args...))
+}
+
+// ParseLifecycleConfigWithWarnings is like ParseLifecycleConfig but also
+// returns a warning for every deprecated element of the configuration,
+// e.g. "Rule[0].Prefix: Prefix is deprecated, use Filter instead". The
+// legacy Prefix given directly in Rule and the "Indefinite" Days are
+// reported.
+func ParseLifecycleConfigWithWarnings(reader io.Reader) (*Lifecycle, *WarningCollector, error) {
+	var lc Lifecycle
+	w := &WarningCollector{}
+	d := xml.NewTokenDecoder(&warningTokenReader{
+		r: &depthLimitedReader{d: xml.NewDecoder(reader)},
+		w: w,
+	})
+	if err := d.Decode(&lc); err != nil {
+		return nil, nil, err
+	}
+	return &lc, w, nil
+}
+
+// warningTokenReader is an xml.TokenReader adding a warning to w for
+// every deprecated element it reads.
+type warningTokenReader struct {
+	r xml.TokenReader
+	w *WarningCollector
+	// path holds the names of the open elements, from the root.
+	path    []string
+	ruleIdx int
+}
+
+func (r *warningTokenReader) Token() (xml.Token, error) {
+	tok, err := r.r.Token()
+	if err != nil {
+		return tok, err
+	}
+	switch t := tok.(type) {
+	case xml.StartElement:
+		r.path = append(r.path, t.Name.Local)
+		if len(r.path) == 2 && t.Name.Local == "Rule" {
+			r.ruleIdx++
+		}
+		if len(r.path) == 3 && r.path[1] == "Rule" && t.Name.Local == "Prefix" {
+			r.w.add("%s: Prefix is deprecated, use Filter instead", r.element())
+		}
+	case xml.EndElement:
+		if len(r.path) > 0 {
+			r.path = r.path[:len(r.path)-1]
+		}
+	case xml.CharData:
+		if len(r.path) > 2 && r.path[1] == "Rule" && r.path[len(r.path)-1] == "Days" && isIndefiniteDays(string(t)) {
+			r.w.add("%s: Indefinite is deprecated, omit Days instead", r.element())
+		}
+	}
+	return tok, nil
+}
+
+// element returns the path of the innermost open element below its
+// Rule, e.g. Rule[0].Transition.Days.
+func (r *warningTokenReader) element() string {
+	return fmt.Sprintf("Rule[%d].%s", r.ruleIdx-1, strings.Join(r.path[2:], "."))
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// WriteTo writes the XML encoding of lc to w. It implements io.WriterTo
+// and is the counterpart of ParseLifecycleConfig.
+func (lc Lifecycle) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := xml.NewEncoder(cw).Encode(lc)
+	return cw.n, err
+}
+
+// MarshalCanonicalJSON returns a compact JSON form of the configuration
+// with sorted keys, e.g. {"Rule":[{"Filter":{"Prefix":"logs/"},...}]},
+// so that equal configurations encode to identical bytes. It mirrors the
+// XML form element for element, Rule, Transition and Tag are always
+// arrays and all values are strings. Rules are kept in order, call Sort
+// first to compare configurations regardless of the order of rules.
+func (lc Lifecycle) MarshalCanonicalJSON() ([]byte, error) {
+	data, err := xml.Marshal(lc)
+	if err != nil {
+		return nil, err
+	}
+	d := xml.NewDecoder(bytes.NewReader(data))
+	if _, err = d.Token(); err != nil { // LifecycleConfiguration
+		return nil, err
+	}
+	v, err := canonicalElement(d)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		v = map[string]interface{}{}
+	}
+	return json.Marshal(v)
+}
+
+// canonicalArrays lists the elements which may be repeated, they are
+// always encoded as arrays by MarshalCanonicalJSON.
+var canonicalArrays = map[string]bool{"Rule": true, "Transition": true, "Tag": true}
+
+// canonicalElement returns the JSON value of the element whose start
+// d just read, either its text or an object of its children.
+func canonicalElement(d *xml.Decoder) (interface{}, error) {
+	var text strings.Builder
+	children := make(map[string][]interface{})
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			v, err := canonicalElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if len(t.Attr) > 0 {
+				obj, ok := v.(map[string]interface{})
+				if !ok {
+					obj = map[string]interface{}{"#text": v}
+				}
+				for _, a := range t.Attr {
+					obj["@"+a.Name.Local] = a.Value
+				}
+				v = obj
+			}
+			children[t.Name.Local] = append(children[t.Name.Local], v)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if len(children) == 0 {
+				return text.String(), nil
+			}
+			obj := make(map[string]interface{}, len(children))
+			for name, values := range children {
+				if len(values) == 1 && !canonicalArrays[name] {
+					obj[name] = values[0]
+				} else {
+					obj[name] = values
+				}
+			}
+			return obj, nil
+		}
+	}
+}
+
+// FreezeToDate returns a copy of lc where every Days based transition
+// and expiration is replaced by the Date it is due on for an object last
+// modified at base, see Transition.ResolveToDate, so the rules behave the
+// same whenever they are evaluated. Noncurrent version actions have no
+// Date form and are kept as is. lc is left unchanged.
+func (lc Lifecycle) FreezeToDate(base time.Time) Lifecycle {
+	frozen := Lifecycle{XMLName: lc.XMLName, Rules: make([]Rule, 0, len(lc.Rules))}
+	for _, r := range lc.Rules {
+		r = r.Clone()
+		r.Expiration = r.Expiration.ResolveToDate(base)
+		for i, t := range r.Transitions {
+			r.Transitions[i] = t.ResolveToDate(base)
+		}
+		frozen.Rules = append(frozen.Rules, r)
+	}
+	return frozen
+}
+
+// Merge returns a configuration holding the rules of lc followed by the
+// rules of other. A rule of other whose ID is already taken is renamed
+// by appending the first free "-N" suffix, N counting from 1. An error
+// is returned if the merged configuration has more than 1000 rules.
+func (lc Lifecycle) Merge(other Lifecycle) (Lifecycle, error) {
+	if len(lc.Rules)+len(other.Rules) > DefaultMaxRules {
+		return Lifecycle{}, errLifecycleTooManyRules
+	}
+	merged := Lifecycle{Rules: make([]Rule, 0, len(lc.Rules)+len(other.Rules))}
+	ids := make(map[string]struct{}, cap(merged.Rules))
+	for _, r := range lc.Rules {
+		ids[r.ID] = struct{}{}
+		merged.Rules = append(merged.Rules, r.Clone())
+	}
+	for _, r := range other.Rules {
+		r = r.Clone()
+		if _, ok := ids[r.ID]; ok && r.ID != "" {
+			for n := 1; ; n++ {
+				id := fmt.Sprintf("%s-%d", r.ID, n)
+				if _, ok := ids[id]; !ok && !hasRuleID(other.Rules, id) {
+					r.ID = id
+					break
+				}
+			}
+		}
+		ids[r.ID] = struct{}{}
+		merged.Rules = append(merged.Rules, r)
+	}
+	return merged, nil
+}
+
+// DeduplicateRules merges every rule of lc into the first rule with
+// the same Status and an equal filter, see Filter.Hash, combining their
+// actions, and returns the number of rules removed. An error is returned,
+// leaving lc unchanged, if two such rules set the same action differently
+// or have transition tiers which can't be combined.
+func (lc *Lifecycle) DeduplicateRules() (int, error) {
+	rules := make([]Rule, 0, len(lc.Rules))
+	indices := make([]int, 0, len(lc.Rules))
+	firsts := make(map[string]int, len(lc.Rules))
+	for i, r := range lc.Rules {
+		key := string(r.Status) + " " + r.Filter.Hash()
+		j, ok := firsts[key]
+		if !ok {
+			firsts[key] = len(rules)
+			rules = append(rules, r.Clone())
+			indices = append(indices, i)
+			continue
+		}
+		merged, ok := rules[j].mergeActions(r)
+		if !ok {
+			return 0, Errorf("%w: %s and %s", errLifecycleConflictingRules,
+				ruleName(indices[j], lc.Rules[indices[j]]), ruleName(i, r))
+		}
+		rules[j] = merged
+	}
+	removed := len(lc.Rules) - len(rules)
+	lc.Rules = rules
+	return removed, nil
+}
+
+func hasRuleID(rules []Rule, id string) bool {
+	for _, r := range rules {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// RuleByID returns a pointer to the rule of lc with the given ID, changes
+// made through it are reflected in lc. It returns false if there is
+// no such rule.
+func (lc *Lifecycle) RuleByID(id string) (*Rule, bool) {
+	for i := range lc.Rules {
+		if lc.Rules[i].ID == id {
+			return &lc.Rules[i], true
+		}
+	}
+	return nil, false
+}
+
+// UpsertRule replaces the rule of lc having the same ID as rule, or
+// appends rule if there is none. The ID of rule must not be empty.
+func (lc *Lifecycle) UpsertRule(rule Rule) error {
+	if rule.ID == "" {
+		return errLifecycleRuleMissingID
+	}
+	if r, ok := lc.RuleByID(rule.ID); ok {
+		*r = rule
+		return nil
+	}
+	lc.Rules = append(lc.Rules, rule)
+	return nil
+}
+
+// RemoveRule removes the rule of lc with the given ID, keeping the order
+// of the remaining rules. It returns false if there is no such rule.
+func (lc *Lifecycle) RemoveRule(id string) bool {
+	for i := range lc.Rules {
+		if lc.Rules[i].ID == id {
+			lc.Rules = append(lc.Rules[:i], lc.Rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// EnableRule sets the Status of the rule of lc with the given ID to
+// Enabled. It returns an error if there is no such rule.
+func (lc *Lifecycle) EnableRule(id string) error {
+	return lc.setRuleStatus(id, Enabled)
+}
+
+// DisableRule sets the Status of the rule of lc with the given ID to
+// Disabled. It returns an error if there is no such rule.
+func (lc *Lifecycle) DisableRule(id string) error {
+	return lc.setRuleStatus(id, Disabled)
+}
+
+func (lc *Lifecycle) setRuleStatus(id string, status Status) error {
+	r, ok := lc.RuleByID(id)
+	if !ok {
+		return Errorf("%w: %q", errLifecycleRuleNotFound, id)
+	}
+	r.Status = status
+	return nil
+}
+
+// RemoveDisabledRules removes all disabled rules of lc, keeping the
+// order of the remaining rules, and returns the number of rules removed.
+func (lc *Lifecycle) RemoveDisabledRules() int {
+	rules := lc.Rules[:0]
+	for _, r := range lc.Rules {
+		if r.Status != Disabled {
+			rules = append(rules, r)
+		}
+	}
+	removed := len(lc.Rules) - len(rules)
+	lc.Rules = rules
+	return removed
+}
+
+// MarshalPretty returns the XML form of the configuration indented with
+// two spaces. Rule elements are emitted in a stable order, ID, Filter
+// (or Prefix), Status and then the actions, so that configurations can
+// be displayed and diffed.
+func (lc Lifecycle) MarshalPretty() ([]byte, error) {
+	type prettyRule struct {
+		XMLName                        xml.Name                       `xml:"Rule"`
+		ID                             string                         `xml:"ID,omitempty"`
+		Filter                         Filter                         `xml:"Filter,omitempty"`
+		Prefix                         Prefix                         `xml:"Prefix,omitempty"`
+		Status                         Status                         `xml:"Status"`
+		Expiration                     Expiration                     `xml:"Expiration,omitempty"`
+		Transitions                    []Transition                   `xml:"Transition,omitempty"`
+		AbortIncompleteMultipartUpload AbortIncompleteMultipartUpload `xml:"AbortIncompleteMultipartUpload,omitempty"`
+		NoncurrentVersionExpiration    NoncurrentVersionExpiration    `xml:"NoncurrentVersionExpiration,omitempty"`
+		NoncurrentVersionTransition    NoncurrentVersionTransition    `xml:"NoncurrentVersionTransition,omitempty"`
+		RawExtra                       []RawElement                   `xml:",any"`
+	}
+	type prettyLifecycle struct {
+		XMLName xml.Name     `xml:"LifecycleConfiguration"`
+		Rules   []prettyRule `xml:"Rule"`
+	}
+	plc := prettyLifecycle{Rules: make([]prettyRule, 0, len(lc.Rules))}
+	for _, r := range lc.Rules {
+		r.normalizeLegacyPrefix()
+		plc.Rules = append(plc.Rules, prettyRule{
+			ID:                             r.ID,
+			Filter:                         r.Filter,
+			Prefix:                         r.Prefix,
+			Status:                         r.Status,
+			Expiration:                     r.Expiration,
+			Transitions:                    r.Transitions,
+			AbortIncompleteMultipartUpload: r.AbortIncompleteMultipartUpload,
+			NoncurrentVersionExpiration:    r.NoncurrentVersionExpiration,
+			NoncurrentVersionTransition:    r.NoncurrentVersionTransition,
+			RawExtra:                       r.RawExtra,
+		})
+	}
+	return xml.MarshalIndent(plc, "", "  ")
+}
+
+// Validate - validates the lifecycle configuration, a configuration
+// without any rule is valid, e.g. to clear the lifecycle of a bucket.
 func (lc Lifecycle) Validate() error {
-	// Lifecycle config can't have more than 1000 rules
-	if len(lc.Rules) > 1000 {
-		return errLifecycleTooManyRules
+	return lc.ValidateWithLimits(DefaultMaxRules)
+}
+
+// ValidateBatch validates every configuration, spreading the work over
+// at most GOMAXPROCS goroutines. The error at index i, nil if valid, is
+// the result of Validate on configs[i].
+func ValidateBatch(configs []Lifecycle) []error {
+	errs := make([]error, len(configs))
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(configs) {
+		workers = len(configs)
 	}
-	// Lifecycle config should have at least one rule
-	if len(lc.Rules) == 0 {
-		return errLifecycleNoRule
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				errs[i] = configs[i].Validate()
+			}
+		}()
+	}
+	for i := range configs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return errs
+}
+
+// ValidateWithLimits is like Validate but allows up to maxRules rules,
+// for backends accepting a different number of rules than AWS S3 does.
+// The error returned for too many rules matches errLifecycleTooManyRules
+// with errors.Is.
+func (lc Lifecycle) ValidateWithLimits(maxRules int) error {
+	// Lifecycle config can't have more than maxRules rules
+	if len(lc.Rules) > maxRules {
+		if maxRules == DefaultMaxRules {
+			return errLifecycleTooManyRules
+		}
+		return Error{err: tooManyRulesError{maxRules: maxRules}}
 	}
 	// Validate all the rules in the lifecycle config
-	for _, r := range lc.Rules {
+	for i, r := range lc.Rules {
 		if err := r.Validate(); err != nil {
-			return err
+			return withRuleIndex(i, err)
 		}
 	}
-	// Make sure Rule ID is unique
+	// Make sure Rule ID is unique, rules without an ID
+	// are not considered since ID is optional.
 	for i := range lc.Rules {
 		if i == len(lc.Rules)-1 {
 			break
 		}
+		if lc.Rules[i].ID == "" {
+			continue
+		}
 		otherRules := lc.Rules[i+1:]
-		for _, otherRule := range otherRules {
+		for j, otherRule := range otherRules {
 			if lc.Rules[i].ID == otherRule.ID {
-				return errLifecycleDuplicateID
+				return withRuleIndex(i+1+j, withElement("ID", errLifecycleDuplicateID))
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateAll is like Validate but reports every error found in the
+// configuration instead of the first one, in the order of the rules.
+func (lc Lifecycle) ValidateAll() []error {
+	var errs []error
+	if len(lc.Rules) > DefaultMaxRules {
+		errs = append(errs, errLifecycleTooManyRules)
+	}
+	seen := make(map[string]struct{}, len(lc.Rules))
+	for i, r := range lc.Rules {
+		for _, err := range r.ValidateAll() {
+			errs = append(errs, withRuleIndex(i, err))
+		}
+		if r.ID == "" {
+			continue
+		}
+		if _, ok := seen[r.ID]; ok {
+			errs = append(errs, withRuleIndex(i, withElement("ID", errLifecycleDuplicateID)))
+		}
+		seen[r.ID] = struct{}{}
+	}
+	return errs
+}
+
+// DuplicateFilters returns the indices of the rules sharing an identical
+// filter, see Filter.Equals, one group per filter used by more than one
+// rule. Groups are ordered by their first rule.
+func (lc Lifecycle) DuplicateFilters() [][]int {
+	var keys []string
+	groups := make(map[string][]int)
+	for i, r := range lc.Rules {
+		r.normalizeLegacyPrefix()
+		key := r.Filter.canonical()
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+	var duplicates [][]int
+	for _, key := range keys {
+		if len(groups[key]) > 1 {
+			duplicates = append(duplicates, groups[key])
+		}
+	}
+	return duplicates
+}
+
+// ValidateOverlaps returns a diagnostic for every pair of enabled rules
+// which can match the same object while one of them expires the object
+// at or before another one transitions it, making the transition moot.
+// Such configurations are valid, this is meant to warn operators.
+func (lc Lifecycle) ValidateOverlaps() []error {
+	var errs []error
+	for i := range lc.Rules {
+		for j := i + 1; j < len(lc.Rules); j++ {
+			a, b := lc.Rules[i], lc.Rules[j]
+			if a.Status == Disabled || b.Status == Disabled || !rulesOverlap(a, b) {
+				continue
+			}
+			if err := expirationPrecedesTransition(ruleName(i, a), a, ruleName(j, b), b); err != nil {
+				errs = append(errs, err)
+			}
+			if err := expirationPrecedesTransition(ruleName(j, b), b, ruleName(i, a), a); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// ValidateReservedPrefixes returns an error for every Enabled rule whose
+// prefix could match objects under one of the reserved prefixes, e.g.
+// where internal metadata is kept, i.e. if either prefix starts with the
+// other. A rule without a prefix applies to every object. Such
+// configurations are valid, this is meant to warn operators.
+func (lc Lifecycle) ValidateReservedPrefixes(reserved []string) []error {
+	var errs []error
+	for i, r := range lc.Rules {
+		if r.Status != Enabled {
+			continue
+		}
+		prefix := r.GetPrefix()
+		for _, rp := range reserved {
+			if rp == "" {
+				continue
+			}
+			if strings.HasPrefix(rp, prefix) || strings.HasPrefix(prefix, rp) {
+				err := Errorf("%w: %q", errLifecycleReservedPrefix, rp)
+				errs = append(errs, withRuleIndex(i, withElement("Filter", err)))
+			}
+		}
+	}
+	return errs
+}
+
+// ruleName identifies a rule in diagnostics by its ID, or by
+// its index when it has none.
+func ruleName(idx int, r Rule) string {
+	if r.ID != "" {
+		return fmt.Sprintf("%q", r.ID)
+	}
+	return fmt.Sprintf("Rule[%d]", idx)
+}
+
+// rulesOverlap returns true if there can be an object matched by both
+// rules, i.e one prefix extends the other and no tag key is required
+// with different values.
+func rulesOverlap(a, b Rule) bool {
+	pa, pb := a.GetPrefix(), b.GetPrefix()
+	if !strings.HasPrefix(pa, pb) && !strings.HasPrefix(pb, pa) {
+		return false
+	}
+	for _, ta := range append([]Tag{a.Filter.Tag}, a.Filter.And.Tags...) {
+		for _, tb := range append([]Tag{b.Filter.Tag}, b.Filter.And.Tags...) {
+			if !ta.IsEmpty() && ta.Key == tb.Key && ta.Value != tb.Value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// expirationPrecedesTransition returns an error if exp expires objects
+// at or before any transition tier of trn, both must be expressed in
+// Days or both in Date to be compared.
+func expirationPrecedesTransition(expName string, exp Rule, trnName string, trn Rule) error {
+	if exp.Expiration.IsNull() {
+		return nil
+	}
+	for _, t := range trn.Transitions {
+		switch {
+		case !exp.Expiration.IsDaysNull() && !t.IsDaysNull():
+			if int(exp.Expiration.Days) <= int(t.Days) {
+				return Errorf("%w: rule %s expires objects after %d days, rule %s transitions them to %s after %d days",
+					errLifecycleConflictingRules, expName, exp.Expiration.Days, trnName, t.StorageClass, t.Days)
+			}
+		case !exp.Expiration.IsDateNull() && !t.IsDateNull():
+			if !exp.Expiration.Date.After(t.Date.Time) {
+				return Errorf("%w: rule %s expires objects on %s, rule %s transitions them to %s on %s",
+					errLifecycleConflictingRules, expName, exp.Expiration.Date.Format("2006-01-02"), trnName, t.StorageClass, t.Date)
 			}
 		}
 	}
 	return nil
 }
 
+// FilterRules returns the enabled rules whose filter matches an object
+// with the given name and tags, regardless of the actions they carry.
+// Object size is not known here, size predicates are not evaluated.
+func (lc Lifecycle) FilterRules(objName string, tags map[string]string) []Rule {
+	var rules []Rule
+	for _, rule := range lc.Rules {
+		if rule.Status == Disabled {
+			continue
+		}
+		if !strings.HasPrefix(objName, rule.Prefix.String()) {
+			continue
+		}
+		f := rule.Filter
+		f.ObjectSizeGreaterThan, f.ObjectSizeLessThan = 0, 0
+		f.And.ObjectSizeGreaterThan, f.And.ObjectSizeLessThan = 0, 0
+		if f.Match(objName, 0, tags) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
 // FilterActionableRules returns the rules actions that need to be executed
 // after evaluating prefix/tag filtering
 func (lc Lifecycle) FilterActionableRules(obj ObjectOpts) []Rule {
@@ -170,6 +998,9 @@ func (lc Lifecycle) FilterActionableRules(obj ObjectOpts) []Rule {
 		if !strings.HasPrefix(obj.Name, rule.GetPrefix()) {
 			continue
 		}
+		if !rule.Filter.TestSize(obj.Size) {
+			continue
+		}
 		// Indicates whether MinIO will remove a delete marker with no
 		// noncurrent versions. If set to true, the delete marker will
 		// be expired; if set to false the policy takes no action. This
@@ -196,8 +1027,9 @@ func (lc Lifecycle) FilterActionableRules(obj ObjectOpts) []Rule {
 
 		if rule.Filter.TestTags(strings.Split(obj.UserTags, "&")) {
 			rules = append(rules, rule)
+			continue
 		}
-		if !rule.Transition.IsNull() {
+		if !rule.isTransitionNull() {
 			rules = append(rules, rule)
 		}
 	}
@@ -209,6 +1041,7 @@ func (lc Lifecycle) FilterActionableRules(obj ObjectOpts) []Rule {
 type ObjectOpts struct {
 	Name             string
 	UserTags         string
+	Size             int64
 	ModTime          time.Time
 	VersionID        string
 	IsLatest         bool
@@ -218,6 +1051,24 @@ type ObjectOpts struct {
 	TransitionStatus string
 	RestoreOngoing   bool
 	RestoreExpires   time.Time
+	// StorageClass is the current storage class of the object, if
+	// known. A transition to it is skipped as a no-op.
+	StorageClass string
+	// BecameCurrentTime is when the version became the current one in
+	// a versioned bucket, if known. Transitions of the current version
+	// are timed from it rather than from ModTime.
+	BecameCurrentTime time.Time
+	// NoncurrentVersionNumber numbers the noncurrent versions of an
+	// object from the most recent one, 1, to the oldest one, 0 if not
+	// known. It is required to expire noncurrent versions with a rule
+	// setting NewerNoncurrentVersions, all of which are kept otherwise.
+	NoncurrentVersionNumber int
+	// StrictDisabledRules makes ComputeActionContext and
+	// ComputeAppliedActionContext fail if a Disabled rule would take an
+	// action on the object were it Enabled, to catch rules disabled by
+	// mistake. ComputeAction and ComputeAppliedAction, which can't
+	// report the error, ignore it. By default Disabled rules are skipped.
+	StrictDisabledRules bool
 }
 
 // ExpiredObjectDeleteMarker returns true if an object version referred to by o
@@ -227,90 +1078,399 @@ func (o ObjectOpts) ExpiredObjectDeleteMarker() bool {
 	return o.DeleteMarker && o.NumVersions == 1
 }
 
+// retainsNoncurrent returns true if the noncurrent version is one of
+// the newest ones n keeps, or if it can't be told.
+func (o ObjectOpts) retainsNoncurrent(n NoncurrentVersionExpiration) bool {
+	if n.NewerNoncurrentVersions <= 0 {
+		return false
+	}
+	return o.NoncurrentVersionNumber <= 0 || o.NoncurrentVersionNumber <= n.NewerNoncurrentVersions
+}
+
+// isPlaceholder returns true if the object is a delete marker or an
+// empty object ending with a slash, as created for directories, neither
+// of which has any data to transition.
+func (o ObjectOpts) isPlaceholder() bool {
+	return o.DeleteMarker || o.Size == 0 && strings.HasSuffix(o.Name, "/")
+}
+
+// transitionBaseTime returns the time transitions of the
+// current version are counted from.
+func (o ObjectOpts) transitionBaseTime() time.Time {
+	if !o.BecameCurrentTime.IsZero() {
+		return o.BecameCurrentTime
+	}
+	return o.ModTime
+}
+
+// inStorageClass returns true if the object is known
+// to be in the given storage class already.
+func (o ObjectOpts) inStorageClass(storageClass string) bool {
+	return o.StorageClass != "" && o.StorageClass == storageClass
+}
+
+// AppliedAction describes the lifecycle action computed for an object
+// along with the rule which triggered it.
+type AppliedAction struct {
+	RuleID string
+	Action Action
+	// StorageClass is the target storage class of a transition
+	// action, it is empty for other actions.
+	StorageClass string
+}
+
 // ComputeAction returns the action to perform by evaluating all lifecycle rules
 // against the object name and its modification time.
 func (lc Lifecycle) ComputeAction(obj ObjectOpts) Action {
-	var action = NoneAction
+	return lc.ComputeAppliedAction(obj).Action
+}
+
+// PeekAction is like ComputeAction but never calls the hook set by
+// SetRuleMatchHook, e.g. for a preliminary evaluation of an object
+// which is evaluated again with ComputeAction before acting on it.
+func (lc Lifecycle) PeekAction(obj ObjectOpts) Action {
+	obj.StrictDisabledRules = false
+	applied, _ := lc.computeAppliedAction(context.Background(), obj)
+	return applied.Action
+}
+
+// ComputeActionContext is like ComputeAction but stops evaluating
+// rules and returns ctx.Err() as soon as ctx is done.
+func (lc Lifecycle) ComputeActionContext(ctx context.Context, obj ObjectOpts) (Action, error) {
+	applied, err := lc.ComputeAppliedActionContext(ctx, obj)
+	return applied.Action, err
+}
+
+// actionPrecedence holds the func set by SetActionPrecedence.
+var actionPrecedence atomic.Value
+
+// actionPrecedenceFunc wraps the precedence, atomic.Value can't hold a nil func.
+type actionPrecedenceFunc struct {
+	fn func(a, b Action) Action
+}
+
+// SetActionPrecedence sets the func picking which of DeleteAction, the
+// expiration of the current version, and TransitionAction is taken when
+// both are due on an object, whether from the same rule or from
+// different rules. Passing nil restores the default, ExpirationFirst,
+// the behavior of AWS S3.
+func SetActionPrecedence(precedence func(a, b Action) Action) {
+	actionPrecedence.Store(actionPrecedenceFunc{fn: precedence})
+}
+
+// ExpirationFirst is an action precedence where expiration wins.
+func ExpirationFirst(a, b Action) Action {
+	if a == DeleteAction || b == DeleteAction {
+		return DeleteAction
+	}
+	return a
+}
+
+// TransitionFirst is an action precedence where transition wins.
+func TransitionFirst(a, b Action) Action {
+	if a == TransitionAction || b == TransitionAction {
+		return TransitionAction
+	}
+	return a
+}
+
+// expirationWins returns true if expiration is preferred
+// over transition by the action precedence.
+func expirationWins() bool {
+	precedence := ExpirationFirst
+	if p, ok := actionPrecedence.Load().(actionPrecedenceFunc); ok && p.fn != nil {
+		precedence = p.fn
+	}
+	return precedence(DeleteAction, TransitionAction) == DeleteAction
+}
+
+// ComputeAppliedAction returns the action to perform by evaluating all lifecycle
+// rules against the object, along with the rule ID and the target storage class
+// of a transition. Rules are evaluated in order and the following precedence
+// applies when more than one rule matches:
+//   - removal of an expired object delete marker, expiration of a noncurrent
+//     version and transition of a noncurrent version are returned as soon as
+//     a matching rule is found.
+//   - expiration of the current version wins over a transition, even when
+//     both become due on the same day or come from different rules, unless
+//     a different precedence is set with SetActionPrecedence.
+//   - otherwise the last matching transition, or removal of an expired
+//     restored copy, is returned.
+func (lc Lifecycle) ComputeAppliedAction(obj ObjectOpts) AppliedAction {
+	// Only a Disabled rule matching in strict mode could fail the
+	// evaluation, the background context is never done.
+	obj.StrictDisabledRules = false
+	applied, _ := lc.ComputeAppliedActionContext(context.Background(), obj)
+	return applied
+}
+
+// ruleMatchHook holds the func set by SetRuleMatchHook.
+var ruleMatchHook atomic.Value
+
+// ruleMatchHookFunc wraps the hook, atomic.Value can't hold a nil func.
+type ruleMatchHookFunc struct {
+	fn func(ruleID string, action Action)
+}
+
+// SetRuleMatchHook sets a func called with the rule ID and the action
+// every time ComputeAction, or any of its variants but PeekAction,
+// selects a rule for an object, e.g. to count rule matches. Passing nil
+// removes the hook.
+// The hook may be called concurrently and must not block.
+func SetRuleMatchHook(hook func(ruleID string, action Action)) {
+	ruleMatchHook.Store(ruleMatchHookFunc{fn: hook})
+}
+
+// ComputeAppliedActionContext is like ComputeAppliedAction but checks ctx
+// between rules, returning ctx.Err() as soon as ctx is done.
+func (lc Lifecycle) ComputeAppliedActionContext(ctx context.Context, obj ObjectOpts) (AppliedAction, error) {
+	applied, err := lc.computeAppliedAction(ctx, obj)
+	if err == nil && applied.Action != NoneAction {
+		if hook, ok := ruleMatchHook.Load().(ruleMatchHookFunc); ok && hook.fn != nil {
+			hook.fn(applied.RuleID, applied.Action)
+		}
+	}
+	return applied, err
+}
+
+// checkDisabledRules returns an error if any Disabled rule
+// would take an action on the object were it Enabled.
+func (lc Lifecycle) checkDisabledRules(obj ObjectOpts) error {
+	for _, rule := range lc.Rules {
+		if rule.Status != Disabled {
+			continue
+		}
+		rule.Status = Enabled
+		if len(Lifecycle{Rules: []Rule{rule}}.FilterActionableRules(obj)) == 0 {
+			continue
+		}
+		if _, ok := evalRule(rule, obj); ok {
+			return Errorf("%w: %s", errLifecycleDisabledRuleMatch, rule.ID)
+		}
+	}
+	return nil
+}
+
+func (lc Lifecycle) computeAppliedAction(ctx context.Context, obj ObjectOpts) (AppliedAction, error) {
+	var applied = AppliedAction{Action: NoneAction}
 	if obj.ModTime.IsZero() {
-		return action
+		return applied, nil
+	}
+	if obj.StrictDisabledRules {
+		if err := lc.checkDisabledRules(obj); err != nil {
+			return applied, err
+		}
 	}
 
+	var expired *AppliedAction
 	for _, rule := range lc.FilterActionableRules(obj) {
-		if obj.ExpiredObjectDeleteMarker() && rule.Expiration.DeleteMarker.val {
-			// Indicates whether MinIO will remove a delete marker with no noncurrent versions.
-			// Only latest marker is removed. If set to true, the delete marker will be expired;
-			// if set to false the policy takes no action. This cannot be specified with Days or
-			// Date in a Lifecycle Expiration Policy.
-			return DeleteVersionAction
+		if err := ctx.Err(); err != nil {
+			return AppliedAction{Action: NoneAction}, err
 		}
-
-		if !rule.NoncurrentVersionExpiration.IsDaysNull() {
-			if obj.VersionID != "" && !obj.IsLatest && !obj.SuccessorModTime.IsZero() {
-				// Non current versions should be deleted if their age exceeds non current days configuration
-				// https://docs.aws.amazon.com/AmazonS3/latest/dev/intro-lifecycle-rules.html#intro-lifecycle-rules-actions
-				if time.Now().After(ExpectedExpiryTime(obj.SuccessorModTime, int(rule.NoncurrentVersionExpiration.NoncurrentDays))) {
-					return DeleteVersionAction
-				}
+		action, ok := evalRule(rule, obj)
+		if !ok {
+			continue
+		}
+		switch action.Action {
+		case TransitionAction, DeleteRestoredAction, DeleteRestoredVersionAction:
+			// A later matching rule may still expire the object.
+			applied = action
+		case DeleteAction:
+			if expirationWins() {
+				return action, nil
 			}
-
-			if obj.VersionID != "" && obj.ExpiredObjectDeleteMarker() {
-				// From https: //docs.aws.amazon.com/AmazonS3/latest/dev/lifecycle-configuration-examples.html :
-				//   The NoncurrentVersionExpiration action in the same Lifecycle configuration removes noncurrent objects X days
-				//   after they become noncurrent. Thus, in this example, all object versions are permanently removed X days after
-				//   object creation. You will have expired object delete markers, but Amazon S3 detects and removes the expired
-				//   object delete markers for you.
-				if time.Now().After(ExpectedExpiryTime(obj.ModTime, int(rule.NoncurrentVersionExpiration.NoncurrentDays))) {
-					return DeleteVersionAction
-				}
+			// A later matching rule may still transition the object.
+			if expired == nil {
+				expired = &action
 			}
+		default:
+			return action, nil
 		}
+	}
+	if expired != nil && applied.Action != TransitionAction {
+		return *expired, nil
+	}
+	return applied, nil
+}
 
-		if !rule.NoncurrentVersionTransition.IsDaysNull() {
-			if obj.VersionID != "" && !obj.IsLatest && !obj.SuccessorModTime.IsZero() && !obj.DeleteMarker && obj.TransitionStatus != TransitionComplete {
-				// Non current versions should be deleted if their age exceeds non current days configuration
-				// https://docs.aws.amazon.com/AmazonS3/latest/dev/intro-lifecycle-rules.html#intro-lifecycle-rules-actions
-				if time.Now().After(ExpectedExpiryTime(obj.SuccessorModTime, int(rule.NoncurrentVersionTransition.NoncurrentDays))) {
-					return TransitionVersionAction
+// ComputeTransitionOnly is like ComputeAppliedAction but only considers
+// transitions, of the current or of a noncurrent version, and ignores
+// every expiration. It never returns a delete action, e.g. for archival
+// workers which must not delete any object.
+func (lc Lifecycle) ComputeTransitionOnly(obj ObjectOpts) AppliedAction {
+	var applied = AppliedAction{Action: NoneAction}
+	if obj.ModTime.IsZero() {
+		return applied
+	}
+	// Expired restored copies are deleted, not transitioned.
+	obj.RestoreExpires = time.Time{}
+	for _, rule := range lc.FilterActionableRules(obj) {
+		rule.Expiration = Expiration{}
+		rule.NoncurrentVersionExpiration = NoncurrentVersionExpiration{}
+		action, ok := evalRule(rule, obj)
+		if !ok {
+			continue
+		}
+		switch action.Action {
+		case TransitionVersionAction:
+			return action
+		case TransitionAction:
+			applied = action
+		}
+	}
+	return applied
+}
+
+// Eval returns the action every matching rule would take on the object,
+// in the order of the rules, without the precedence ComputeAction applies
+// between rules. Rules without an action due on the object are omitted.
+func (lc Lifecycle) Eval(obj ObjectOpts) []AppliedAction {
+	if obj.ModTime.IsZero() {
+		return nil
+	}
+	var actions []AppliedAction
+	for _, rule := range lc.FilterActionableRules(obj) {
+		if action, ok := evalRule(rule, obj); ok {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// PendingAction is an action a rule is scheduled to take on an object.
+type PendingAction struct {
+	RuleID string
+	Action Action
+	// StorageClass is the target storage class of a transition
+	// action, it is empty for other actions.
+	StorageClass string
+	// Time is when the action becomes due.
+	Time time.Time
+}
+
+// PendingActions returns the actions matching rules are yet to take on
+// the object after now, sorted chronologically, e.g. to show a timeline
+// of the object. Unlike ComputeAction, every scheduled action is listed
+// even if an earlier one, like expiration, would make it moot.
+func (lc Lifecycle) PendingActions(obj ObjectOpts, now time.Time) []PendingAction {
+	if obj.ModTime.IsZero() {
+		return nil
+	}
+	var pending []PendingAction
+	add := func(rule Rule, action Action, storageClass string, at time.Time, due bool) {
+		if !due {
+			pending = append(pending, PendingAction{RuleID: rule.ID, Action: action, StorageClass: storageClass, Time: at})
+		}
+	}
+	for _, rule := range lc.FilterActionableRules(obj) {
+		if obj.VersionID != "" && !obj.IsLatest && !obj.SuccessorModTime.IsZero() {
+			if nve := rule.NoncurrentVersionExpiration; !nve.IsDaysNull() && !obj.retainsNoncurrent(nve) {
+				at := ExpectedExpiryTime(obj.SuccessorModTime, int(nve.NoncurrentDays))
+				add(rule, DeleteVersionAction, "", at, now.After(at))
+			}
+			if nvt := rule.NoncurrentVersionTransition; !nvt.IsDaysNull() && !obj.isPlaceholder() &&
+				obj.TransitionStatus != TransitionComplete && !obj.inStorageClass(nvt.StorageClass) {
+				at := ExpectedExpiryTime(obj.SuccessorModTime, int(nvt.NoncurrentDays))
+				add(rule, TransitionVersionAction, nvt.StorageClass, at, now.After(at))
+			}
+			continue
+		}
+		if obj.VersionID != "" && (!obj.IsLatest || obj.DeleteMarker) {
+			continue
+		}
+		if obj.TransitionStatus != TransitionComplete && !obj.isPlaceholder() {
+			for _, t := range rule.Transitions {
+				if at, ok := t.NextActionTime(obj.transitionBaseTime()); ok && !obj.inStorageClass(t.StorageClass) {
+					add(rule, TransitionAction, t.StorageClass, at, !now.Before(at))
 				}
 			}
 		}
+		if at, ok := rule.Expiration.NextActionTime(obj.ModTime); ok {
+			add(rule, DeleteAction, "", at, now.After(at))
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].Time.Before(pending[j].Time)
+	})
+	return pending
+}
 
-		// Remove the object or simply add a delete marker (once) in a versioned bucket
-		if obj.VersionID == "" || obj.IsLatest && !obj.DeleteMarker {
-			switch {
-			case !rule.Expiration.IsDateNull():
-				if time.Now().UTC().After(rule.Expiration.Date.Time) {
-					return DeleteAction
-				}
-			case !rule.Expiration.IsDaysNull():
-				if time.Now().UTC().After(ExpectedExpiryTime(obj.ModTime, int(rule.Expiration.Days))) {
-					return DeleteAction
-				}
+// evalRule returns the action rule takes on the object, or false if
+// the rule has no action due on it.
+func evalRule(rule Rule, obj ObjectOpts) (applied AppliedAction, ok bool) {
+	// All day counts are evaluated in UTC, independent of the
+	// server time zone and its daylight saving time changes.
+	now := time.Now().UTC()
+
+	if obj.ExpiredObjectDeleteMarker() && rule.Expiration.DeleteMarker.val {
+		// Indicates whether MinIO will remove a delete marker with no noncurrent versions.
+		// Only latest marker is removed. If set to true, the delete marker will be expired;
+		// if set to false the policy takes no action. This cannot be specified with Days or
+		// Date in a Lifecycle Expiration Policy.
+		return AppliedAction{RuleID: rule.ID, Action: DeleteVersionAction}, true
+	}
+
+	if !rule.NoncurrentVersionExpiration.IsDaysNull() {
+		if obj.VersionID != "" && !obj.IsLatest && !obj.SuccessorModTime.IsZero() {
+			// Non current versions should be deleted if their age exceeds non current days configuration
+			// https://docs.aws.amazon.com/AmazonS3/latest/dev/intro-lifecycle-rules.html#intro-lifecycle-rules-actions
+			if now.After(ExpectedExpiryTime(obj.SuccessorModTime, int(rule.NoncurrentVersionExpiration.NoncurrentDays))) &&
+				!obj.retainsNoncurrent(rule.NoncurrentVersionExpiration) {
+				return AppliedAction{RuleID: rule.ID, Action: DeleteVersionAction}, true
 			}
+		}
 
-			if obj.TransitionStatus != TransitionComplete {
-				switch {
-				case !rule.Transition.IsDateNull():
-					if time.Now().UTC().After(rule.Transition.Date.Time) {
-						action = TransitionAction
-					}
-				case !rule.Transition.IsDaysNull():
-					if time.Now().UTC().After(ExpectedExpiryTime(obj.ModTime, int(rule.Transition.Days))) {
-						action = TransitionAction
-					}
-				}
+		if obj.VersionID != "" && obj.ExpiredObjectDeleteMarker() {
+			// From https: //docs.aws.amazon.com/AmazonS3/latest/dev/lifecycle-configuration-examples.html :
+			//   The NoncurrentVersionExpiration action in the same Lifecycle configuration removes noncurrent objects X days
+			//   after they become noncurrent. Thus, in this example, all object versions are permanently removed X days after
+			//   object creation. You will have expired object delete markers, but Amazon S3 detects and removes the expired
+			//   object delete markers for you.
+			if now.After(ExpectedExpiryTime(obj.ModTime, int(rule.NoncurrentVersionExpiration.NoncurrentDays))) {
+				return AppliedAction{RuleID: rule.ID, Action: DeleteVersionAction}, true
 			}
-			if !obj.RestoreExpires.IsZero() && time.Now().After(obj.RestoreExpires) {
-				if obj.VersionID != "" {
-					action = DeleteRestoredVersionAction
-				} else {
-					action = DeleteRestoredAction
-				}
+		}
+	}
+
+	if !rule.NoncurrentVersionTransition.IsDaysNull() {
+		if obj.VersionID != "" && !obj.IsLatest && !obj.SuccessorModTime.IsZero() && !obj.isPlaceholder() && obj.TransitionStatus != TransitionComplete {
+			// Non current versions should be deleted if their age exceeds non current days configuration
+			// https://docs.aws.amazon.com/AmazonS3/latest/dev/intro-lifecycle-rules.html#intro-lifecycle-rules-actions
+			if now.After(ExpectedExpiryTime(obj.SuccessorModTime, int(rule.NoncurrentVersionTransition.NoncurrentDays))) &&
+				!obj.inStorageClass(rule.NoncurrentVersionTransition.StorageClass) {
+				return AppliedAction{RuleID: rule.ID, Action: TransitionVersionAction, StorageClass: rule.NoncurrentVersionTransition.StorageClass}, true
 			}
+		}
+	}
 
+	// Remove the object or simply add a delete marker (once) in a versioned bucket
+	if obj.VersionID == "" || obj.IsLatest && !obj.DeleteMarker {
+		expired := rule.Expiration.IsExpirationImmediate(obj.ModTime, now)
+
+		var (
+			transition Transition
+			due        bool
+		)
+		if obj.TransitionStatus != TransitionComplete && !obj.isPlaceholder() {
+			transition, due = rule.TransitionSchedule(now).Due(obj.transitionBaseTime())
+			due = due && !obj.inStorageClass(transition.StorageClass)
+		}
+		if expired && (!due || expirationWins()) {
+			return AppliedAction{RuleID: rule.ID, Action: DeleteAction}, true
+		}
+		if due {
+			applied, ok = AppliedAction{RuleID: rule.ID, Action: TransitionAction, StorageClass: transition.StorageClass}, true
 		}
+		if !obj.RestoreExpires.IsZero() && now.After(obj.RestoreExpires) {
+			if obj.VersionID != "" {
+				applied, ok = AppliedAction{RuleID: rule.ID, Action: DeleteRestoredVersionAction}, true
+			} else {
+				applied, ok = AppliedAction{RuleID: rule.ID, Action: DeleteRestoredAction}, true
+			}
+		}
+
 	}
-	return action
+	return applied, ok
 }
 
 // ExpectedExpiryTime calculates the expiry, transition or restore date/time based on a object modtime.
@@ -318,6 +1478,8 @@ func (lc Lifecycle) ComputeAction(obj ObjectOpts) Action {
 // modification time plus the number of transition/restore days.
 //   e.g. If the object modtime is `Thu May 21 13:42:50 GMT 2020` and the object should
 //       transition in 1 day, then the expected transition time is `Fri, 23 May 2020 00:00:00 GMT`
+// The computation is done in UTC, a day is always 24 hours whatever the time zone of
+// modTime or of the server.
 func ExpectedExpiryTime(modTime time.Time, days int) time.Time {
 	t := modTime.UTC().Add(time.Duration(days+1) * 24 * time.Hour)
 	return t.Truncate(24 * time.Hour)
@@ -337,7 +1499,8 @@ func (lc Lifecycle) PredictExpiryTime(obj ObjectOpts) (string, time.Time) {
 	// Iterate over all actionable rules and find the earliest
 	// expiration date and its associated rule ID.
 	for _, rule := range lc.FilterActionableRules(obj) {
-		if !rule.NoncurrentVersionExpiration.IsDaysNull() && !obj.IsLatest && obj.VersionID != "" {
+		if !rule.NoncurrentVersionExpiration.IsDaysNull() && !obj.IsLatest && obj.VersionID != "" &&
+			!obj.retainsNoncurrent(rule.NoncurrentVersionExpiration) {
 			return rule.ID, ExpectedExpiryTime(obj.SuccessorModTime, int(rule.NoncurrentVersionExpiration.NoncurrentDays))
 		}
 
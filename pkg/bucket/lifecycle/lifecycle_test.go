@@ -18,8 +18,16 @@ package lifecycle
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -64,11 +72,11 @@ func TestParseAndValidateLifecycleConfig(t *testing.T) {
 			expectedParsingErr:    errDuplicatedXMLTag,
 			expectedValidationErr: nil,
 		},
-		{ // lifecycle config with no rules
+		{ // lifecycle config with no rules, clearing the lifecycle
 			inputConfig: `<LifecycleConfiguration>
 		                          </LifecycleConfiguration>`,
 			expectedParsingErr:    nil,
-			expectedValidationErr: errLifecycleNoRule,
+			expectedValidationErr: nil,
 		},
 		{ // lifecycle config with rules having overlapping prefix
 			inputConfig:           `<LifecycleConfiguration><Rule><ID>rule1</ID><Status>Enabled</Status><Filter><Prefix>/a/b</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule><Rule><ID>rule2</ID><Status>Enabled</Status><Filter><And><Prefix>/a/b/c</Prefix><Tag><Key>key1</Key><Value>val1</Value></Tag></And></Filter><Expiration><Days>3</Days></Expiration></Rule></LifecycleConfiguration> `,
@@ -80,6 +88,11 @@ func TestParseAndValidateLifecycleConfig(t *testing.T) {
 			expectedParsingErr:    nil,
 			expectedValidationErr: errLifecycleDuplicateID,
 		},
+		{ // lifecycle config with rules without ID
+			inputConfig:           `<LifecycleConfiguration><Rule><Status>Enabled</Status><Filter><Prefix>/a/b</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule><Rule><Status>Enabled</Status><Filter><Prefix>/x/z</Prefix></Filter><Expiration><Days>4</Days></Expiration></Rule></LifecycleConfiguration>`,
+			expectedParsingErr:    nil,
+			expectedValidationErr: nil,
+		},
 		// Missing <Tag> in <And>
 		{
 			inputConfig:           `<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Rule><ID>sample-rule-2</ID><Filter><And><Prefix>/a/b/c</Prefix></And></Filter><Status>Enabled</Status><Expiration><Days>1</Days></Expiration></Rule></LifecycleConfiguration>`,
@@ -106,13 +119,74 @@ func TestParseAndValidateLifecycleConfig(t *testing.T) {
 				return
 			}
 			err = lc.Validate()
-			if err != tc.expectedValidationErr {
+			if !errors.Is(err, tc.expectedValidationErr) {
 				t.Fatalf("%d: Expected %v during parsing but got %v", i+1, tc.expectedValidationErr, err)
 			}
 		})
 	}
 }
 
+// TestLifecycleMaxRules checks the limit on the number of rules
+// in a lifecycle configuration
+func TestLifecycleMaxRules(t *testing.T) {
+	newLifecycle := func(numRules int) Lifecycle {
+		var lc Lifecycle
+		for i := 0; i < numRules; i++ {
+			lc.Rules = append(lc.Rules, Rule{
+				ID:         fmt.Sprintf("rule-%d", i),
+				Status:     Enabled,
+				Filter:     Filter{Prefix: Prefix{string: fmt.Sprintf("prefix-%d", i), set: true}},
+				Expiration: Expiration{Days: ExpirationDays(3), set: true},
+			})
+		}
+		return lc
+	}
+
+	testCases := []struct {
+		numRules    int
+		expectedErr error
+	}{
+		{numRules: 1, expectedErr: nil},
+		{numRules: 1000, expectedErr: nil},
+		{numRules: 1001, expectedErr: errLifecycleTooManyRules},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if err := newLifecycle(tc.numRules).Validate(); err != tc.expectedErr {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+		})
+	}
+
+	customTestCases := []struct {
+		numRules    int
+		maxRules    int
+		expectedErr error
+	}{
+		{numRules: 1001, maxRules: 2000, expectedErr: nil},
+		{numRules: 2000, maxRules: 2000, expectedErr: nil},
+		{numRules: 2001, maxRules: 2000, expectedErr: errLifecycleTooManyRules},
+		{numRules: 11, maxRules: 10, expectedErr: errLifecycleTooManyRules},
+		{numRules: 1001, maxRules: DefaultMaxRules, expectedErr: errLifecycleTooManyRules},
+	}
+	for i, tc := range customTestCases {
+		t.Run(fmt.Sprintf("Custom test %d", i+1), func(t *testing.T) {
+			err := newLifecycle(tc.numRules).ValidateWithLimits(tc.maxRules)
+			if !errors.Is(err, tc.expectedErr) || (err == nil) != (tc.expectedErr == nil) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if err != nil {
+				if _, ok := err.(Error); !ok {
+					t.Fatalf("%d: Expected an error of type Error but got %T", i+1, err)
+				}
+				if expected := fmt.Sprintf("Lifecycle configuration allows a maximum of %d rules", tc.maxRules); err.Error() != expected {
+					t.Fatalf("%d: Expected %q but got %q", i+1, expected, err.Error())
+				}
+			}
+		})
+	}
+}
+
 // TestMarshalLifecycleConfig checks if lifecycleconfig xml
 // marshaling/unmarshaling can handle output from each other
 func TestMarshalLifecycleConfig(t *testing.T) {
@@ -201,6 +275,7 @@ func TestComputeActions(t *testing.T) {
 		inputConfig    string
 		objectName     string
 		objectTags     string
+		objectSize     int64
 		objectModTime  time.Time
 		expectedAction Action
 	}{
@@ -336,6 +411,62 @@ func TestComputeActions(t *testing.T) {
 			objectModTime:  time.Now().UTC().Add(-24 * time.Hour), // Created 1 day ago
 			expectedAction: DeleteAction,
 		},
+		// Object smaller than ObjectSizeGreaterThan should not transition
+		{
+			inputConfig:    `<LifecycleConfiguration><Rule><Filter><ObjectSizeGreaterThan>1048576</ObjectSizeGreaterThan></Filter><Status>Enabled</Status><Transition><Days>5</Days><StorageClass>WARM</StorageClass></Transition></Rule></LifecycleConfiguration>`,
+			objectName:     "foodir/fooobject",
+			objectSize:     1024,
+			objectModTime:  time.Now().UTC().Add(-10 * 24 * time.Hour), // Created 10 days ago
+			expectedAction: NoneAction,
+		},
+		// Object larger than ObjectSizeGreaterThan should transition
+		{
+			inputConfig:    `<LifecycleConfiguration><Rule><Filter><ObjectSizeGreaterThan>1048576</ObjectSizeGreaterThan></Filter><Status>Enabled</Status><Transition><Days>5</Days><StorageClass>WARM</StorageClass></Transition></Rule></LifecycleConfiguration>`,
+			objectName:     "foodir/fooobject",
+			objectSize:     2 << 20,
+			objectModTime:  time.Now().UTC().Add(-10 * 24 * time.Hour), // Created 10 days ago
+			expectedAction: TransitionAction,
+		},
+		// Size bounds in And are honored along with the prefix
+		{
+			inputConfig:    `<LifecycleConfiguration><Rule><Filter><And><Prefix>foodir/</Prefix><ObjectSizeLessThan>1024</ObjectSizeLessThan></And></Filter><Status>Enabled</Status><Expiration><Days>5</Days></Expiration></Rule></LifecycleConfiguration>`,
+			objectName:     "foodir/fooobject",
+			objectSize:     4096,
+			objectModTime:  time.Now().UTC().Add(-10 * 24 * time.Hour), // Created 10 days ago
+			expectedAction: NoneAction,
+		},
+		{
+			inputConfig:    `<LifecycleConfiguration><Rule><Filter><And><Prefix>foodir/</Prefix><ObjectSizeLessThan>1024</ObjectSizeLessThan></And></Filter><Status>Enabled</Status><Expiration><Days>5</Days></Expiration></Rule></LifecycleConfiguration>`,
+			objectName:     "foodir/fooobject",
+			objectSize:     512,
+			objectModTime:  time.Now().UTC().Add(-10 * 24 * time.Hour), // Created 10 days ago
+			expectedAction: DeleteAction,
+		},
+		// Size bound and tags in And must all hold
+		{
+			inputConfig:    `<LifecycleConfiguration><Rule><Filter><And><ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan><Tag><Key>tag1</Key><Value>value1</Value></Tag></And></Filter><Status>Enabled</Status><Expiration><Days>5</Days></Expiration></Rule></LifecycleConfiguration>`,
+			objectName:     "foodir/fooobject",
+			objectTags:     "tag1=value1",
+			objectSize:     512,
+			objectModTime:  time.Now().UTC().Add(-10 * 24 * time.Hour), // Created 10 days ago
+			expectedAction: NoneAction,
+		},
+		{
+			inputConfig:    `<LifecycleConfiguration><Rule><Filter><And><ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan><Tag><Key>tag1</Key><Value>value1</Value></Tag></And></Filter><Status>Enabled</Status><Expiration><Days>5</Days></Expiration></Rule></LifecycleConfiguration>`,
+			objectName:     "foodir/fooobject",
+			objectTags:     "tag1=value2",
+			objectSize:     2048,
+			objectModTime:  time.Now().UTC().Add(-10 * 24 * time.Hour), // Created 10 days ago
+			expectedAction: NoneAction,
+		},
+		{
+			inputConfig:    `<LifecycleConfiguration><Rule><Filter><And><ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan><Tag><Key>tag1</Key><Value>value1</Value></Tag></And></Filter><Status>Enabled</Status><Expiration><Days>5</Days></Expiration></Rule></LifecycleConfiguration>`,
+			objectName:     "foodir/fooobject",
+			objectTags:     "tag1=value1",
+			objectSize:     2048,
+			objectModTime:  time.Now().UTC().Add(-10 * 24 * time.Hour), // Created 10 days ago
+			expectedAction: DeleteAction,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -348,6 +479,7 @@ func TestComputeActions(t *testing.T) {
 			if resultAction := lc.ComputeAction(ObjectOpts{
 				Name:     tc.objectName,
 				UserTags: tc.objectTags,
+				Size:     tc.objectSize,
 				ModTime:  tc.objectModTime,
 				IsLatest: true,
 			}); resultAction != tc.expectedAction {
@@ -395,6 +527,21 @@ func TestHasActiveRules(t *testing.T) {
 			prefix:         "foodir/foobject",
 			expectedNonRec: false, expectedRec: false,
 		},
+		{ // all rules disabled
+			inputConfig:    `<LifecycleConfiguration><Rule><Filter></Filter><Status>Disabled</Status><Expiration><Days>5</Days></Expiration></Rule><Rule><Filter></Filter><Status>Disabled</Status><Transition><Days>5</Days><StorageClass>WARM</StorageClass></Transition></Rule></LifecycleConfiguration>`,
+			prefix:         "",
+			expectedNonRec: false, expectedRec: false,
+		},
+		{ // enabled rule without any action
+			inputConfig:    `<LifecycleConfiguration><Rule><Filter></Filter><Status>Enabled</Status></Rule></LifecycleConfiguration>`,
+			prefix:         "",
+			expectedNonRec: false, expectedRec: false,
+		},
+		{ // one active abort rule among disabled ones
+			inputConfig:    `<LifecycleConfiguration><Rule><Filter></Filter><Status>Disabled</Status><Expiration><Days>5</Days></Expiration></Rule><Rule><Filter></Filter><Status>Enabled</Status><AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule></LifecycleConfiguration>`,
+			prefix:         "",
+			expectedNonRec: true, expectedRec: true,
+		},
 	}
 
 	for i, tc := range testCases {
@@ -415,3 +562,2044 @@ func TestHasActiveRules(t *testing.T) {
 
 	}
 }
+
+// TestHasActiveObjectRules checks if rules only aborting incomplete
+// multipart uploads are left out
+func TestHasActiveObjectRules(t *testing.T) {
+	testCases := []struct {
+		inputConfig string
+		expected    bool
+	}{
+		{
+			inputConfig: `<LifecycleConfiguration><Rule><Filter></Filter><Status>Enabled</Status><AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule></LifecycleConfiguration>`,
+			expected:    false,
+		},
+		{
+			inputConfig: `<LifecycleConfiguration><Rule><Filter></Filter><Status>Enabled</Status><Expiration><Days>5</Days></Expiration><AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule></LifecycleConfiguration>`,
+			expected:    true,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			lc, err := ParseLifecycleConfig(strings.NewReader(tc.inputConfig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !lc.HasActiveRules("", true) {
+				t.Fatalf("%d: Expected active rules", i+1)
+			}
+			if got := lc.HasActiveObjectRules("", true); got != tc.expected {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestComputeAppliedAction checks which rule wins when several rules
+// match the same object
+func TestComputeAppliedAction(t *testing.T) {
+	testCases := []struct {
+		inputConfig    string
+		objectModTime  time.Time
+		expectedAction AppliedAction
+	}{
+		{ // Expiration wins over a transition due on the same day
+			inputConfig:    `<LifecycleConfiguration><Rule><ID>transition</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Transition><Days>5</Days><StorageClass>WARM</StorageClass></Transition></Rule><Rule><ID>expire</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>5</Days></Expiration></Rule></LifecycleConfiguration>`,
+			objectModTime:  time.Now().UTC().Add(-10 * 24 * time.Hour), // Created 10 days ago
+			expectedAction: AppliedAction{RuleID: "expire", Action: DeleteAction},
+		},
+		{ // Only the transition is due
+			inputConfig:    `<LifecycleConfiguration><Rule><ID>transition</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Transition><Days>5</Days><StorageClass>WARM</StorageClass></Transition></Rule><Rule><ID>expire</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule></LifecycleConfiguration>`,
+			objectModTime:  time.Now().UTC().Add(-10 * 24 * time.Hour), // Created 10 days ago
+			expectedAction: AppliedAction{RuleID: "transition", Action: TransitionAction, StorageClass: "WARM"},
+		},
+		{ // Neither rule is due yet
+			inputConfig:    `<LifecycleConfiguration><Rule><ID>transition</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Transition><Days>15</Days><StorageClass>WARM</StorageClass></Transition></Rule><Rule><ID>expire</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule></LifecycleConfiguration>`,
+			objectModTime:  time.Now().UTC().Add(-10 * 24 * time.Hour), // Created 10 days ago
+			expectedAction: AppliedAction{Action: NoneAction},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(tc.inputConfig)))
+			if err != nil {
+				t.Fatalf("Got unexpected error: %v", err)
+			}
+			obj := ObjectOpts{
+				Name:     "foodir/fooobject",
+				ModTime:  tc.objectModTime,
+				IsLatest: true,
+			}
+			if got := lc.ComputeAppliedAction(obj); got != tc.expectedAction {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedAction, got)
+			}
+			if got := lc.ComputeAction(obj); got != tc.expectedAction.Action {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedAction.Action, got)
+			}
+		})
+	}
+}
+
+func TestLifecycleString(t *testing.T) {
+	inputConfig := `<LifecycleConfiguration><Rule><ID>archive</ID><Filter><Prefix>logs/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>365</Days></Expiration><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule><Rule><ID>tagged</ID><Filter><Tag><Key>key1</Key><Value>val1</Value></Tag></Filter><Status>Disabled</Status><Expiration><Date>2024-01-01T00:00:00Z</Date></Expiration></Rule></LifecycleConfiguration>`
+	lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(inputConfig)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `Lifecycle(2 rules)
+  Rule(ID=archive, Status=Enabled, Prefix=logs/, Expiration(Days=365), Transition(Days=30, StorageClass=GLACIER))
+  Rule(ID=tagged, Status=Disabled, Tags=key1=val1, Expiration(Date=2024-01-01))`
+	if got := lc.String(); got != expected {
+		t.Fatalf("Expected %s but got %s", expected, got)
+	}
+}
+
+func TestComputeAppliedActionTransitionTiers(t *testing.T) {
+	inputConfig := `<LifecycleConfiguration><Rule><ID>tiers</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Transition><Days>30</Days><StorageClass>STANDARD_IA</StorageClass></Transition><Transition><Days>90</Days><StorageClass>GLACIER</StorageClass></Transition><Transition><Days>365</Days><StorageClass>DEEP_ARCHIVE</StorageClass></Transition></Rule></LifecycleConfiguration>`
+	lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(inputConfig)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = lc.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	got := lc.ComputeAppliedAction(ObjectOpts{
+		Name:     "foodir/fooobject",
+		ModTime:  time.Now().UTC().Add(-120 * 24 * time.Hour), // Created 120 days ago
+		IsLatest: true,
+	})
+	expected := AppliedAction{RuleID: "tiers", Action: TransitionAction, StorageClass: "GLACIER"}
+	if got != expected {
+		t.Fatalf("Expected %v but got %v", expected, got)
+	}
+}
+
+func TestValidationErrorPath(t *testing.T) {
+	testCases := []struct {
+		inputConfig  string
+		expectedPath string
+		expectedErr  error
+	}{
+		{
+			inputConfig: `<LifecycleConfiguration>
+				<Rule><ID>1</ID><Status>Enabled</Status><Filter><Prefix>p</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+				<Rule><ID>2</ID><Status>Enabled</Status><Filter><Prefix>p</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+				<Rule><ID>3</ID><Status>Enabled</Status><Filter><Prefix>p</Prefix></Filter><Transition><Days>1</Days></Transition></Rule>
+			</LifecycleConfiguration>`,
+			expectedPath: "Rule[2].Transition[0].StorageClass",
+			expectedErr:  errXMLNotWellFormed,
+		},
+		{
+			inputConfig: `<LifecycleConfiguration>
+				<Rule><ID>1</ID><Status>Enabled</Status><Filter><Prefix>p</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+				<Rule><ID>2</ID><Status>Unknown</Status><Filter><Prefix>p</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+			</LifecycleConfiguration>`,
+			expectedPath: "Rule[1].Status",
+			expectedErr:  errInvalidRuleStatus,
+		},
+		{
+			inputConfig: `<LifecycleConfiguration>
+				<Rule><ID>1</ID><Status>Enabled</Status><Filter><Prefix>p</Prefix></Filter>
+				<Transition><Days>10</Days><StorageClass>A</StorageClass></Transition>
+				<Transition><Days>5</Days><StorageClass>B</StorageClass></Transition></Rule>
+			</LifecycleConfiguration>`,
+			expectedPath: "Rule[0].Transition[1].Days",
+			expectedErr:  errTransitionNotMonotonic,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var lc Lifecycle
+			if err := xml.Unmarshal([]byte(tc.inputConfig), &lc); err != nil {
+				t.Fatal(err)
+			}
+			err := lc.Validate()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			var ve ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("%d: Expected a ValidationError but got %T", i+1, err)
+			}
+			if ve.Path() != tc.expectedPath {
+				t.Fatalf("%d: Expected path %s but got %s", i+1, tc.expectedPath, ve.Path())
+			}
+			if _, ok := err.(Error); !ok {
+				t.Fatalf("%d: Expected error of type Error but got %T", i+1, err)
+			}
+		})
+	}
+}
+
+func TestFilterRules(t *testing.T) {
+	lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(`<LifecycleConfiguration>
+		<Rule><ID>logs</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+		<Rule><ID>tmp-tag</ID><Status>Enabled</Status><Filter><Tag><Key>tmp</Key><Value>true</Value></Tag></Filter><Expiration><Days>1</Days></Expiration></Rule>
+		<Rule><ID>all</ID><Status>Enabled</Status><Filter></Filter><Expiration><Days>30</Days></Expiration></Rule>
+		<Rule><ID>logs-and</ID><Status>Enabled</Status><Filter><And><Prefix>logs/</Prefix><Tag><Key>tmp</Key><Value>true</Value></Tag></And></Filter><Expiration><Days>2</Days></Expiration></Rule>
+		<Rule><ID>legacy</ID><Status>Enabled</Status><Prefix>data/</Prefix><Expiration><Days>1</Days></Expiration></Rule>
+		<Rule><ID>disabled</ID><Status>Disabled</Status><Filter></Filter><Expiration><Days>1</Days></Expiration></Rule>
+		<Rule><ID>large</ID><Status>Enabled</Status><Filter><ObjectSizeGreaterThan>1048576</ObjectSizeGreaterThan></Filter><Expiration><Days>1</Days></Expiration></Rule>
+	</LifecycleConfiguration>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		objName     string
+		tags        map[string]string
+		expectedIDs []string
+	}{
+		{
+			objName:     "logs/a.log",
+			tags:        map[string]string{"tmp": "true"},
+			expectedIDs: []string{"logs", "tmp-tag", "all", "logs-and", "large"},
+		},
+		{
+			objName:     "logs/a.log",
+			expectedIDs: []string{"logs", "all", "large"},
+		},
+		{
+			objName:     "data/a.csv",
+			tags:        map[string]string{"tmp": "false"},
+			expectedIDs: []string{"all", "legacy", "large"},
+		},
+		{
+			objName:     "other",
+			expectedIDs: []string{"all", "large"},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var ids []string
+			for _, rule := range lc.FilterRules(tc.objName, tc.tags) {
+				ids = append(ids, rule.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tc.expectedIDs) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+func TestValidateOverlaps(t *testing.T) {
+	testCases := []struct {
+		inputConfig    string
+		expectedErrors []string
+	}{
+		{ // Expiration before transition on a shared prefix
+			inputConfig: `<LifecycleConfiguration>
+				<Rule><ID>archive</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>
+				<Rule><ID>cleanup</ID><Status>Enabled</Status><Filter><Prefix>logs/app/</Prefix></Filter><Expiration><Days>20</Days></Expiration></Rule>
+			</LifecycleConfiguration>`,
+			expectedErrors: []string{
+				`Lifecycle configuration has overlapping rules with conflicting actions: rule "cleanup" expires objects after 20 days, rule "archive" transitions them to GLACIER after 30 days`,
+			},
+		},
+		{ // Disjoint prefixes
+			inputConfig: `<LifecycleConfiguration>
+				<Rule><ID>archive</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>
+				<Rule><ID>cleanup</ID><Status>Enabled</Status><Filter><Prefix>tmp/</Prefix></Filter><Expiration><Days>20</Days></Expiration></Rule>
+			</LifecycleConfiguration>`,
+		},
+		{ // Expiration after transition
+			inputConfig: `<LifecycleConfiguration>
+				<Rule><ID>archive</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>
+				<Rule><ID>cleanup</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>365</Days></Expiration></Rule>
+			</LifecycleConfiguration>`,
+		},
+		{ // Same tag key with different values
+			inputConfig: `<LifecycleConfiguration>
+				<Rule><ID>archive</ID><Status>Enabled</Status><Filter><Tag><Key>class</Key><Value>cold</Value></Tag></Filter><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>
+				<Rule><ID>cleanup</ID><Status>Enabled</Status><Filter><Tag><Key>class</Key><Value>tmp</Value></Tag></Filter><Expiration><Days>1</Days></Expiration></Rule>
+			</LifecycleConfiguration>`,
+		},
+		{ // Disabled rules are ignored
+			inputConfig: `<LifecycleConfiguration>
+				<Rule><ID>archive</ID><Status>Enabled</Status><Filter></Filter><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>
+				<Rule><ID>cleanup</ID><Status>Disabled</Status><Filter></Filter><Expiration><Days>1</Days></Expiration></Rule>
+			</LifecycleConfiguration>`,
+		},
+		{ // Dates are compared, rules without ID are named by index
+			inputConfig: `<LifecycleConfiguration>
+				<Rule><Status>Enabled</Status><Filter></Filter><Expiration><Date>2024-01-01T00:00:00Z</Date></Expiration></Rule>
+				<Rule><Status>Enabled</Status><Filter></Filter><Transition><Date>2024-01-01T00:00:00Z</Date><StorageClass>GLACIER</StorageClass></Transition></Rule>
+			</LifecycleConfiguration>`,
+			expectedErrors: []string{
+				`Lifecycle configuration has overlapping rules with conflicting actions: rule Rule[0] expires objects on 2024-01-01, rule Rule[1] transitions them to GLACIER on 2024-01-01`,
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(tc.inputConfig)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs := lc.ValidateOverlaps()
+			if len(errs) != len(tc.expectedErrors) {
+				t.Fatalf("%d: Expected %d errors but got %v", i+1, len(tc.expectedErrors), errs)
+			}
+			for j, err := range errs {
+				if !errors.Is(err, errLifecycleConflictingRules) {
+					t.Fatalf("%d: Expected %v but got %v", i+1, errLifecycleConflictingRules, err)
+				}
+				if err.Error() != tc.expectedErrors[j] {
+					t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedErrors[j], err)
+				}
+			}
+		})
+	}
+}
+
+func TestComputeActionContext(t *testing.T) {
+	lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(`<LifecycleConfiguration><Rule><Status>Enabled</Status><Filter></Filter><Expiration><Days>1</Days></Expiration></Rule></LifecycleConfiguration>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := ObjectOpts{Name: "obj", ModTime: time.Now().UTC().Add(-10 * 24 * time.Hour)}
+
+	action, err := lc.ComputeActionContext(context.Background(), obj)
+	if err != nil || action != DeleteAction {
+		t.Fatalf("Expected %v but got %v, %v", DeleteAction, action, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	action, err = lc.ComputeActionContext(ctx, obj)
+	if err != ctx.Err() {
+		t.Fatalf("Expected %v but got %v", ctx.Err(), err)
+	}
+	if action != NoneAction {
+		t.Fatalf("Expected %v but got %v", NoneAction, action)
+	}
+}
+
+// TestParseNamespacedLifecycleConfig checks if documents with namespace
+// prefixed elements parse and are marshaled back without prefixes
+func TestParseNamespacedLifecycleConfig(t *testing.T) {
+	inputConfig := `<s3:LifecycleConfiguration xmlns:s3="http://s3.amazonaws.com/doc/2006-03-01/">` +
+		`<s3:Rule><s3:ID>tiered</s3:ID><s3:Status>Enabled</s3:Status><s3:Filter><s3:And><s3:Prefix>logs/</s3:Prefix><s3:Tag><s3:Key>k</s3:Key><s3:Value>v</s3:Value></s3:Tag></s3:And></s3:Filter>` +
+		`<s3:Expiration><s3:Days>365</s3:Days></s3:Expiration><s3:Transition><s3:Days>30</s3:Days><s3:StorageClass>WARM</s3:StorageClass></s3:Transition>` +
+		`<s3:NoncurrentVersionExpiration><s3:NoncurrentDays>7</s3:NoncurrentDays></s3:NoncurrentVersionExpiration></s3:Rule>` +
+		`<s3:Rule><s3:ID>abort</s3:ID><s3:Status>Enabled</s3:Status><s3:Filter><s3:Prefix>uploads/</s3:Prefix></s3:Filter>` +
+		`<s3:AbortIncompleteMultipartUpload><s3:DaysAfterInitiation>2</s3:DaysAfterInitiation></s3:AbortIncompleteMultipartUpload></s3:Rule>` +
+		`</s3:LifecycleConfiguration>`
+	expectedConfig := `<LifecycleConfiguration>` +
+		`<Rule><ID>tiered</ID><Status>Enabled</Status><Filter><And><Prefix>logs/</Prefix><Tag><Key>k</Key><Value>v</Value></Tag></And></Filter>` +
+		`<Expiration><Days>365</Days></Expiration><Transition><Days>30</Days><StorageClass>WARM</StorageClass></Transition>` +
+		`<NoncurrentVersionExpiration><NoncurrentDays>7</NoncurrentDays></NoncurrentVersionExpiration></Rule>` +
+		`<Rule><ID>abort</ID><Status>Enabled</Status><Filter><Prefix>uploads/</Prefix></Filter>` +
+		`<AbortIncompleteMultipartUpload><DaysAfterInitiation>2</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule>` +
+		`</LifecycleConfiguration>`
+
+	lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(inputConfig)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = lc.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := xml.Marshal(lc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expectedConfig {
+		t.Fatalf("Expected %s but got %s", expectedConfig, string(b))
+	}
+}
+
+// TestRuleOrderRoundTrip checks that the rules of testdata/rule_order.golden
+// are marshaled back in their original order
+func TestRuleOrderRoundTrip(t *testing.T) {
+	golden, err := ioutil.ReadFile(filepath.Join("testdata", "rule_order.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden = bytes.TrimSpace(golden)
+	lc, err := ParseLifecycleConfig(bytes.NewReader(golden))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = lc.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range lc.Rules {
+		ids = append(ids, r.ID)
+	}
+	if got := strings.Join(ids, ","); got != "zeta,alpha,mid,beta,aardvark" {
+		t.Fatalf("Expected zeta,alpha,mid,beta,aardvark but got %s", got)
+	}
+	got, err := xml.Marshal(lc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, golden) {
+		t.Fatalf("Expected\n%s\nbut got\n%s", golden, got)
+	}
+}
+
+// TestMarshalPretty checks the indented output of a configuration
+// against testdata/pretty.golden
+func TestMarshalPretty(t *testing.T) {
+	inputConfig := `<LifecycleConfiguration>` +
+		`<Rule><ID>tiered</ID><Status>Enabled</Status><Filter><And><Prefix>logs/</Prefix><Tag><Key>k</Key><Value>v</Value></Tag></And></Filter>` +
+		`<Expiration><Days>365</Days></Expiration><Transition><Days>30</Days><StorageClass>WARM</StorageClass></Transition>` +
+		`<Transition><Days>90</Days><StorageClass>COLD</StorageClass></Transition><NoncurrentVersionExpiration><NoncurrentDays>7</NoncurrentDays></NoncurrentVersionExpiration></Rule>` +
+		`<Rule><ID>legacy</ID><Status>Disabled</Status><Prefix>tmp/</Prefix><Expiration><Date>2024-01-01T00:00:00Z</Date></Expiration></Rule>` +
+		`<Rule><ID>abort</ID><Status>Enabled</Status><Filter><Prefix>uploads/</Prefix></Filter>` +
+		`<AbortIncompleteMultipartUpload><DaysAfterInitiation>2</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule>` +
+		`</LifecycleConfiguration>`
+	lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(inputConfig)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := lc.MarshalPretty()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ioutil.ReadFile(filepath.Join("testdata", "pretty.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Fatalf("Expected\n%s\nbut got\n%s", expected, got)
+	}
+
+	// Pretty output parses back into the same configuration
+	plc, err := ParseLifecycleConfig(bytes.NewReader(got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plc.String() != lc.String() {
+		t.Fatalf("Expected %s but got %s", lc, plc)
+	}
+}
+
+func TestLifecycleMerge(t *testing.T) {
+	parse := func(rules ...string) Lifecycle {
+		lc, err := ParseLifecycleConfig(bytes.NewReader([]byte("<LifecycleConfiguration>" + strings.Join(rules, "") + "</LifecycleConfiguration>")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *lc
+	}
+	rule := func(id string) string {
+		return `<Rule><ID>` + id + `</ID><Status>Enabled</Status><Filter><Prefix></Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>`
+	}
+
+	testCases := []struct {
+		lc, other   Lifecycle
+		expectedIDs []string
+	}{
+		{
+			lc:          parse(rule("expire")),
+			other:       parse(rule("archive")),
+			expectedIDs: []string{"expire", "archive"},
+		},
+		{
+			lc:          parse(rule("rule"), rule("rule-1")),
+			other:       parse(rule("rule"), rule("other")),
+			expectedIDs: []string{"rule", "rule-1", "rule-2", "other"},
+		},
+		{ // A later rule of other keeps its own ID
+			lc:          parse(rule("rule")),
+			other:       parse(rule("rule"), rule("rule-1")),
+			expectedIDs: []string{"rule", "rule-2", "rule-1"},
+		},
+		{ // Empty IDs are left for Validate to fill
+			lc:          parse(rule("")),
+			other:       parse(rule("")),
+			expectedIDs: []string{"", ""},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			merged, err := tc.lc.Merge(tc.other)
+			if err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			var ids []string
+			for _, r := range merged.Rules {
+				ids = append(ids, r.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tc.expectedIDs) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedIDs, ids)
+			}
+			if err = merged.Validate(); err != nil {
+				t.Fatalf("%d: Expected merged config to be valid but got %v", i+1, err)
+			}
+		})
+	}
+
+	var lc, other Lifecycle
+	for i := 0; i < 600; i++ {
+		lc.Rules = append(lc.Rules, Rule{ID: fmt.Sprintf("rule-%d", i)})
+	}
+	for i := 0; i < 400; i++ {
+		other.Rules = append(other.Rules, Rule{ID: fmt.Sprintf("rule-%d", i)})
+	}
+	if merged, err := lc.Merge(other); err != nil || len(merged.Rules) != 1000 {
+		t.Fatalf("Expected 1000 rules but got %d, %v", len(merged.Rules), err)
+	}
+	other.Rules = append(other.Rules, Rule{ID: "one-too-many"})
+	if _, err := lc.Merge(other); err != errLifecycleTooManyRules {
+		t.Fatalf("Expected %v but got %v", errLifecycleTooManyRules, err)
+	}
+}
+
+func TestLifecycleEval(t *testing.T) {
+	lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(`<LifecycleConfiguration>
+		<Rule><ID>archive</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Transition><Days>5</Days><StorageClass>WARM</StorageClass></Transition></Rule>
+		<Rule><ID>expire</ID><Status>Enabled</Status><Filter><Prefix></Prefix></Filter><Expiration><Days>7</Days></Expiration></Rule>
+		<Rule><ID>tagged</ID><Status>Enabled</Status><Filter><Tag><Key>tmp</Key><Value>true</Value></Tag></Filter><Expiration><Days>1</Days></Expiration></Rule>
+		<Rule><ID>later</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>365</Days></Expiration></Rule>
+		<Rule><ID>other</ID><Status>Enabled</Status><Filter><Prefix>data/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+	</LifecycleConfiguration>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := ObjectOpts{
+		Name:     "logs/app.log",
+		UserTags: "tmp=true",
+		ModTime:  time.Now().UTC().Add(-10 * 24 * time.Hour), // Created 10 days ago
+		IsLatest: true,
+	}
+	expected := []AppliedAction{
+		{RuleID: "archive", Action: TransitionAction, StorageClass: "WARM"},
+		{RuleID: "expire", Action: DeleteAction},
+		{RuleID: "tagged", Action: DeleteAction},
+	}
+	got := lc.Eval(obj)
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %v but got %v", expected, got)
+	}
+	// ComputeAction collapses them to the first expiration
+	if applied := lc.ComputeAppliedAction(obj); applied != expected[1] {
+		t.Fatalf("Expected %v but got %v", expected[1], applied)
+	}
+	if got = lc.Eval(ObjectOpts{Name: "logs/app.log"}); got != nil {
+		t.Fatalf("Expected no actions without a modification time but got %v", got)
+	}
+}
+
+// TestParseMalformedLifecycleConfig checks that malformed input is
+// rejected with an error rather than a panic
+func TestParseMalformedLifecycleConfig(t *testing.T) {
+	testCases := []string{
+		"",
+		"<",
+		"<LifecycleConfiguration>",
+		"<LifecycleConfiguration><Rule><ID>rule</ID>",
+		"<LifecycleConfiguration><Rule></Filter></Rule></LifecycleConfiguration>",
+		"<LifecycleConfiguration><Rule><Expiration><Days>1</Days></Rule></LifecycleConfiguration>",
+		"<LifecycleConfiguration><Rule><Transition><Days>abc</Days></Transition></Rule></LifecycleConfiguration>",
+		"<LifecycleConfiguration><Rule><Expiration><Date>yesterday</Date></Expiration></Rule></LifecycleConfiguration>",
+		"<LifecycleConfiguration><Rule><Filter><And><Tag><Key>k</Key>" + strings.Repeat("<Value>", 1000),
+		strings.Repeat("<Rule>", 100000),
+		"<LifecycleConfiguration><Rule><Filter>" + strings.Repeat("<And>", maxXMLDepth) + strings.Repeat("</And>", maxXMLDepth) + "</Filter></Rule></LifecycleConfiguration>",
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if _, err := ParseLifecycleConfig(strings.NewReader(tc)); err == nil {
+				t.Fatalf("%d: Expected an error but got none", i+1)
+			}
+		})
+	}
+
+	deep := "<LifecycleConfiguration>" + strings.Repeat("<Rule>", maxXMLDepth)
+	if _, err := ParseLifecycleConfig(strings.NewReader(deep)); err != errLifecycleTooDeeplyNested {
+		t.Fatalf("Expected %v but got %v", errLifecycleTooDeeplyNested, err)
+	}
+}
+
+// checkParseLifecycleConfig checks that any configuration that parses
+// survives a write and re-parse, and that any configuration that
+// validates still validates afterwards.
+func checkParseLifecycleConfig(t *testing.T, data []byte) {
+	lc, err := ParseLifecycleConfig(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	valid := lc.Validate() == nil
+
+	var buf bytes.Buffer
+	if _, err = lc.WriteTo(&buf); err != nil {
+		t.Fatalf("Failed to write parsed configuration: %v", err)
+	}
+	reparsed, err := ParseLifecycleConfig(&buf)
+	if err != nil {
+		t.Fatalf("Failed to parse written configuration %q: %v", buf.String(), err)
+	}
+	if valid {
+		if err = reparsed.Validate(); err != nil {
+			t.Fatalf("Written configuration %q no longer validates: %v", buf.String(), err)
+		}
+	}
+}
+
+// TestParseLifecycleConfigSeedCorpus replays the fuzz seed corpus, so
+// that it is exercised by toolchains without native fuzzing as well
+func TestParseLifecycleConfigSeedCorpus(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "fuzz", "FuzzParseLifecycleConfig", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("Expected a seed corpus but found none")
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			b, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Corpus entries are a "go test fuzz v1" header followed
+			// by a single []byte("...") value.
+			lines := strings.SplitN(strings.TrimSpace(string(b)), "\n", 2)
+			if len(lines) != 2 || lines[0] != "go test fuzz v1" ||
+				!strings.HasPrefix(lines[1], "[]byte(") || !strings.HasSuffix(lines[1], ")") {
+				t.Fatalf("Malformed corpus entry %s", file)
+			}
+			data, err := strconv.Unquote(strings.TrimSuffix(strings.TrimPrefix(lines[1], "[]byte("), ")"))
+			if err != nil {
+				t.Fatalf("Malformed corpus entry %s: %v", file, err)
+			}
+			checkParseLifecycleConfig(t, []byte(data))
+		})
+	}
+}
+
+func TestLifecycleWriteTo(t *testing.T) {
+	input := `<LifecycleConfiguration><Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>prefix/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule></LifecycleConfiguration>`
+	lc, err := ParseLifecycleConfig(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	n, err := lc.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Fatalf("Expected %d bytes written but got %d", buf.Len(), n)
+	}
+	if buf.String() != input {
+		t.Fatalf("Expected %s but got %s", input, buf.String())
+	}
+
+	reparsed, err := ParseLifecycleConfig(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = reparsed.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDaysComputationTimeZone checks that day counts are unaffected by
+// the time zone of the process, including across a daylight saving
+// time change
+func TestDaysComputationTimeZone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+	defer func(local *time.Location) { time.Local = local }(time.Local)
+	time.Local = loc
+
+	// Daylight saving time starts on 14 March 2021 in New York, the 23
+	// hours long local day lies between modTime, 11 March 03:30 UTC, and
+	// the expected time.
+	modTime := time.Date(2021, time.March, 10, 22, 30, 0, 0, loc)
+	expected := time.Date(2021, time.April, 11, 0, 0, 0, 0, time.UTC)
+	if got := ExpectedExpiryTime(modTime, 30); !got.Equal(expected) || got.Location() != time.UTC {
+		t.Fatalf("Expected %v but got %v", expected, got)
+	}
+
+	tr := Transition{Days: 30, StorageClass: "GLACIER", daysSet: true, set: true}
+	if got, _ := tr.NextActionTime(modTime); !got.Equal(expected) {
+		t.Fatalf("Expected %v but got %v", expected, got)
+	}
+	if ok, _ := tr.ShouldTransition(modTime, expected.Add(-time.Nanosecond)); ok {
+		t.Fatalf("Expected no transition before %v", expected)
+	}
+	if ok, _ := tr.ShouldTransition(modTime, expected); !ok {
+		t.Fatalf("Expected a transition at %v", expected)
+	}
+
+	// Evaluate a whole configuration with the threshold crossed within
+	// the last day, modTime given in the local time zone.
+	lc := Lifecycle{Rules: []Rule{{
+		ID:          "rule",
+		Status:      Enabled,
+		Filter:      Filter{Prefix: Prefix{string: "", set: true}},
+		Transitions: []Transition{tr},
+	}}}
+	now := time.Now().UTC()
+	today := now.Truncate(24 * time.Hour)
+	due := today.Add(-30 * 24 * time.Hour).Add(-time.Hour).In(loc)
+	if action := lc.ComputeAction(ObjectOpts{Name: "obj", ModTime: due}); action != TransitionAction {
+		t.Fatalf("Expected %v but got %v", TransitionAction, action)
+	}
+	notDue := today.Add(-29 * 24 * time.Hour).Add(time.Minute).In(loc)
+	if action := lc.ComputeAction(ObjectOpts{Name: "obj", ModTime: notDue}); action != NoneAction {
+		t.Fatalf("Expected %v but got %v", NoneAction, action)
+	}
+
+	// Dates given with a +00:00 offset are accepted and stored in UTC.
+	var exp Expiration
+	if err = xml.Unmarshal([]byte("<Expiration><Date>2021-03-14T00:00:00+00:00</Date></Expiration>"), &exp); err != nil {
+		t.Fatal(err)
+	}
+	if exp.Date.Location() != time.UTC {
+		t.Fatalf("Expected a date in UTC but got %v", exp.Date.Location())
+	}
+}
+
+func TestLifecycleRuleByID(t *testing.T) {
+	newRule := func(id, prefix string) Rule {
+		return Rule{
+			ID:         id,
+			Status:     Enabled,
+			Filter:     Filter{Prefix: Prefix{string: prefix, set: true}},
+			Expiration: Expiration{Days: ExpirationDays(3), set: true},
+		}
+	}
+	lc := Lifecycle{Rules: []Rule{newRule("rule-1", "a/"), newRule("rule-2", "b/")}}
+
+	r, ok := lc.RuleByID("rule-2")
+	if !ok || r.GetPrefix() != "b/" {
+		t.Fatalf("Expected to find rule-2 but got %v, %v", r, ok)
+	}
+	r.Status = Disabled
+	if lc.Rules[1].Status != Disabled {
+		t.Fatal("Expected changes through RuleByID to update the configuration")
+	}
+	if r, ok = lc.RuleByID("rule-3"); ok || r != nil {
+		t.Fatalf("Expected no rule-3 but got %v", r)
+	}
+
+	// Update
+	if err := lc.UpsertRule(newRule("rule-1", "c/")); err != nil {
+		t.Fatal(err)
+	}
+	if len(lc.Rules) != 2 || lc.Rules[0].GetPrefix() != "c/" {
+		t.Fatalf("Expected rule-1 to be replaced but got %v", lc)
+	}
+
+	// Insert
+	if err := lc.UpsertRule(newRule("rule-3", "d/")); err != nil {
+		t.Fatal(err)
+	}
+	if len(lc.Rules) != 3 || lc.Rules[2].ID != "rule-3" {
+		t.Fatalf("Expected rule-3 to be appended but got %v", lc)
+	}
+
+	// Empty ID
+	if err := lc.UpsertRule(newRule("", "e/")); err != errLifecycleRuleMissingID {
+		t.Fatalf("Expected %v but got %v", errLifecycleRuleMissingID, err)
+	}
+	if len(lc.Rules) != 3 {
+		t.Fatalf("Expected 3 rules but got %d", len(lc.Rules))
+	}
+}
+
+func TestLifecycleRemoveRules(t *testing.T) {
+	newLifecycle := func(statuses ...Status) Lifecycle {
+		var lc Lifecycle
+		for i, status := range statuses {
+			lc.Rules = append(lc.Rules, Rule{
+				ID:         fmt.Sprintf("rule-%d", i),
+				Status:     status,
+				Filter:     Filter{Prefix: Prefix{string: fmt.Sprintf("prefix-%d", i), set: true}},
+				Expiration: Expiration{Days: ExpirationDays(3), set: true},
+			})
+		}
+		return lc
+	}
+	ruleIDs := func(lc Lifecycle) string {
+		var ids []string
+		for _, r := range lc.Rules {
+			ids = append(ids, r.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	lc := newLifecycle(Enabled, Enabled, Enabled)
+	if !lc.RemoveRule("rule-1") {
+		t.Fatal("Expected rule-1 to be removed")
+	}
+	if ids := ruleIDs(lc); ids != "rule-0,rule-2" {
+		t.Fatalf("Expected rule-0,rule-2 but got %s", ids)
+	}
+	if lc.RemoveRule("rule-1") {
+		t.Fatal("Expected rule-1 to be already removed")
+	}
+
+	lc = newLifecycle(Disabled, Enabled, Disabled, Disabled, Enabled)
+	if n := lc.RemoveDisabledRules(); n != 3 {
+		t.Fatalf("Expected 3 rules removed but got %d", n)
+	}
+	if ids := ruleIDs(lc); ids != "rule-1,rule-4" {
+		t.Fatalf("Expected rule-1,rule-4 but got %s", ids)
+	}
+	if n := lc.RemoveDisabledRules(); n != 0 {
+		t.Fatalf("Expected no rule removed but got %d", n)
+	}
+}
+
+func TestLifecycleHash(t *testing.T) {
+	parse := func(s string) Lifecycle {
+		lc, err := ParseLifecycleConfig(strings.NewReader(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *lc
+	}
+
+	lc := parse(`<LifecycleConfiguration>` +
+		`<Rule><ID>a</ID><Status>Enabled</Status><Filter><And><Prefix>logs/</Prefix><Tag><Key>k1</Key><Value>v1</Value></Tag><Tag><Key>k2</Key><Value>v2</Value></Tag></And></Filter><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>` +
+		`<Rule><ID>b</ID><Status>Enabled</Status><Filter><Prefix>tmp/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule>` +
+		`</LifecycleConfiguration>`)
+	// Same configuration with rules, elements and tags reordered
+	// and storage classes in a different case.
+	defer SetNormalizeStorageClass(true)
+	SetNormalizeStorageClass(false)
+	reordered := parse(`<LifecycleConfiguration>` +
+		`<Rule><Expiration><Days>3</Days></Expiration><Filter><Prefix>tmp/</Prefix></Filter><Status>Enabled</Status><ID>b</ID></Rule>` +
+		`<Rule><Transition><StorageClass>glacier</StorageClass><Days>30</Days></Transition><Filter><And><Tag><Key>k2</Key><Value>v2</Value></Tag><Tag><Key>k1</Key><Value>v1</Value></Tag><Prefix>logs/</Prefix></And></Filter><ID>a</ID><Status>Enabled</Status></Rule>` +
+		`</LifecycleConfiguration>`)
+	changed := parse(`<LifecycleConfiguration>` +
+		`<Rule><ID>a</ID><Status>Enabled</Status><Filter><And><Prefix>logs/</Prefix><Tag><Key>k1</Key><Value>v1</Value></Tag><Tag><Key>k2</Key><Value>v2</Value></Tag></And></Filter><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>` +
+		`<Rule><ID>b</ID><Status>Enabled</Status><Filter><Prefix>tmp/</Prefix></Filter><Expiration><Days>4</Days></Expiration></Rule>` +
+		`</LifecycleConfiguration>`)
+
+	if lc.Hash() != lc.Hash() {
+		t.Fatal("Expected hash to be deterministic")
+	}
+	if lc.Hash() != reordered.Hash() {
+		t.Fatalf("Expected reordered configuration to hash identically, %s != %s", lc.Hash(), reordered.Hash())
+	}
+	if lc.Hash() == changed.Hash() {
+		t.Fatal("Expected a changed day to change the hash")
+	}
+	if len(lc.Rules[0].Filter.And.Tags) != 2 || lc.Rules[0].Filter.And.Tags[0].Key != "k1" || reordered.Rules[1].Filter.And.Tags[0].Key != "k2" {
+		t.Fatal("Expected Hash to leave the configuration unchanged")
+	}
+}
+
+func TestActionPrecedence(t *testing.T) {
+	testCases := []struct {
+		inputConfig string
+		precedence  func(a, b Action) Action
+		expected    AppliedAction
+	}{
+		{ // Expiration and transition due the same day in one rule
+			inputConfig: `<LifecycleConfiguration><Rule><ID>both</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule></LifecycleConfiguration>`,
+			precedence:  ExpirationFirst,
+			expected:    AppliedAction{RuleID: "both", Action: DeleteAction},
+		},
+		{
+			inputConfig: `<LifecycleConfiguration><Rule><ID>both</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule></LifecycleConfiguration>`,
+			precedence:  TransitionFirst,
+			expected:    AppliedAction{RuleID: "both", Action: TransitionAction, StorageClass: "GLACIER"},
+		},
+		{ // Expiration and transition from different rules
+			inputConfig: `<LifecycleConfiguration><Rule><ID>expire</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule><Rule><ID>transition</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule></LifecycleConfiguration>`,
+			precedence:  ExpirationFirst,
+			expected:    AppliedAction{RuleID: "expire", Action: DeleteAction},
+		},
+		{
+			inputConfig: `<LifecycleConfiguration><Rule><ID>expire</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule><Rule><ID>transition</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule></LifecycleConfiguration>`,
+			precedence:  TransitionFirst,
+			expected:    AppliedAction{RuleID: "transition", Action: TransitionAction, StorageClass: "GLACIER"},
+		},
+		{ // Transition not due, expiration is taken whatever the precedence
+			inputConfig: `<LifecycleConfiguration><Rule><ID>both</ID><Filter><Prefix>foodir/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration><Transition><Days>60</Days><StorageClass>GLACIER</StorageClass></Transition></Rule></LifecycleConfiguration>`,
+			precedence:  TransitionFirst,
+			expected:    AppliedAction{RuleID: "both", Action: DeleteAction},
+		},
+	}
+
+	defer SetActionPrecedence(nil)
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(tc.inputConfig)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = lc.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			SetActionPrecedence(tc.precedence)
+			got := lc.ComputeAppliedAction(ObjectOpts{
+				Name:     "foodir/fooobject",
+				ModTime:  time.Now().UTC().Add(-40 * 24 * time.Hour), // Created 40 days ago
+				IsLatest: true,
+			})
+			if got != tc.expected {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestLifecycleStorageClasses(t *testing.T) {
+	testCases := []struct {
+		inputConfig string
+		expected    []string
+	}{
+		{
+			inputConfig: `<LifecycleConfiguration><Rule><ID>expire</ID><Filter><Prefix>a/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>3</Days></Expiration></Rule></LifecycleConfiguration>`,
+			expected:    nil,
+		},
+		{ // Repeated and distinct classes across tiers, rules and noncurrent transitions
+			inputConfig: `<LifecycleConfiguration>` +
+				`<Rule><ID>tiers</ID><Filter><Prefix>a/</Prefix></Filter><Status>Enabled</Status><Transition><Days>30</Days><StorageClass>STANDARD_IA</StorageClass></Transition><Transition><Days>90</Days><StorageClass>GLACIER</StorageClass></Transition><NoncurrentVersionTransition><NoncurrentDays>10</NoncurrentDays><StorageClass>WARM</StorageClass></NoncurrentVersionTransition></Rule>` +
+				`<Rule><ID>glacier</ID><Filter><Prefix>b/</Prefix></Filter><Status>Disabled</Status><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition><NoncurrentVersionTransition><NoncurrentDays>10</NoncurrentDays><StorageClass>STANDARD_IA</StorageClass></NoncurrentVersionTransition></Rule>` +
+				`</LifecycleConfiguration>`,
+			expected: []string{"GLACIER", "STANDARD_IA", "WARM"},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			lc, err := ParseLifecycleConfig(strings.NewReader(tc.inputConfig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := lc.StorageClasses(); fmt.Sprint(got) != fmt.Sprint(tc.expected) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRuleMatchHook(t *testing.T) {
+	inputConfig := `<LifecycleConfiguration>` +
+		`<Rule><ID>expire</ID><Filter><Prefix>tmp/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule>` +
+		`<Rule><ID>archive</ID><Filter><Prefix>logs/</Prefix></Filter><Status>Enabled</Status><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>` +
+		`</LifecycleConfiguration>`
+	lc, err := ParseLifecycleConfig(strings.NewReader(inputConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	SetRuleMatchHook(func(ruleID string, action Action) {
+		counts[fmt.Sprintf("%s:%v", ruleID, action)]++
+	})
+	defer SetRuleMatchHook(nil)
+
+	old := time.Now().UTC().Add(-40 * 24 * time.Hour)
+	recent := time.Now().UTC().Add(-10 * 24 * time.Hour)
+	objects := []ObjectOpts{
+		{Name: "tmp/a", ModTime: old, IsLatest: true},
+		{Name: "tmp/b", ModTime: old, IsLatest: true},
+		{Name: "tmp/c", ModTime: recent, IsLatest: true},
+		{Name: "logs/a", ModTime: old, IsLatest: true},
+		{Name: "other/a", ModTime: old, IsLatest: true},
+	}
+	for _, obj := range objects {
+		lc.ComputeAction(obj)
+	}
+
+	expected := map[string]int{"expire:DeleteAction": 2, "archive:TransitionAction": 1}
+	if fmt.Sprint(counts) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %v but got %v", expected, counts)
+	}
+
+	lc.PeekAction(objects[0])
+	if counts["expire:DeleteAction"] != 2 {
+		t.Fatal("Expected no call from PeekAction")
+	}
+
+	SetRuleMatchHook(nil)
+	lc.ComputeAction(objects[0])
+	if counts["expire:DeleteAction"] != 2 {
+		t.Fatal("Expected no call once the hook is removed")
+	}
+}
+
+// TestComputeActionCurrentStorageClass checks that a transition to the
+// storage class an object is already in is skipped
+func TestComputeActionCurrentStorageClass(t *testing.T) {
+	testCases := []struct {
+		inputConfig  string
+		storageClass string
+		expected     AppliedAction
+	}{
+		{ // Current storage class unknown
+			inputConfig: `<LifecycleConfiguration><Rule><ID>archive</ID><Filter><Prefix></Prefix></Filter><Status>Enabled</Status><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule></LifecycleConfiguration>`,
+			expected:    AppliedAction{RuleID: "archive", Action: TransitionAction, StorageClass: "GLACIER"},
+		},
+		{ // Already in the target storage class
+			inputConfig:  `<LifecycleConfiguration><Rule><ID>archive</ID><Filter><Prefix></Prefix></Filter><Status>Enabled</Status><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule></LifecycleConfiguration>`,
+			storageClass: "GLACIER",
+			expected:     AppliedAction{Action: NoneAction},
+		},
+		{ // In another storage class
+			inputConfig:  `<LifecycleConfiguration><Rule><ID>archive</ID><Filter><Prefix></Prefix></Filter><Status>Enabled</Status><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule></LifecycleConfiguration>`,
+			storageClass: "STANDARD_IA",
+			expected:     AppliedAction{RuleID: "archive", Action: TransitionAction, StorageClass: "GLACIER"},
+		},
+		{ // Falls through to the transition of a later rule
+			inputConfig:  `<LifecycleConfiguration><Rule><ID>deep</ID><Filter><Prefix></Prefix></Filter><Status>Enabled</Status><Transition><Days>20</Days><StorageClass>DEEP_ARCHIVE</StorageClass></Transition></Rule><Rule><ID>archive</ID><Filter><Prefix></Prefix></Filter><Status>Enabled</Status><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule></LifecycleConfiguration>`,
+			storageClass: "GLACIER",
+			expected:     AppliedAction{RuleID: "deep", Action: TransitionAction, StorageClass: "DEEP_ARCHIVE"},
+		},
+		{ // Falls through to the expiration, whatever the precedence
+			inputConfig:  `<LifecycleConfiguration><Rule><ID>both</ID><Filter><Prefix></Prefix></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule></LifecycleConfiguration>`,
+			storageClass: "GLACIER",
+			expected:     AppliedAction{RuleID: "both", Action: DeleteAction},
+		},
+	}
+
+	defer SetActionPrecedence(nil)
+	SetActionPrecedence(TransitionFirst)
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			lc, err := ParseLifecycleConfig(strings.NewReader(tc.inputConfig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := lc.ComputeAppliedAction(ObjectOpts{
+				Name:         "fooobject",
+				ModTime:      time.Now().UTC().Add(-40 * 24 * time.Hour), // Created 40 days ago
+				IsLatest:     true,
+				StorageClass: tc.storageClass,
+			})
+			if got != tc.expected {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected, got)
+			}
+		})
+	}
+
+	// Noncurrent versions
+	lc, err := ParseLifecycleConfig(strings.NewReader(`<LifecycleConfiguration><Rule><ID>noncurrent</ID><Filter><Prefix></Prefix></Filter><Status>Enabled</Status><NoncurrentVersionTransition><NoncurrentDays>5</NoncurrentDays><StorageClass>GLACIER</StorageClass></NoncurrentVersionTransition></Rule></LifecycleConfiguration>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := ObjectOpts{
+		Name:             "fooobject",
+		ModTime:          time.Now().UTC().Add(-40 * 24 * time.Hour),
+		SuccessorModTime: time.Now().UTC().Add(-10 * 24 * time.Hour),
+		VersionID:        "version",
+		StorageClass:     "GLACIER",
+	}
+	if action := lc.ComputeAction(obj); action != NoneAction {
+		t.Fatalf("Expected %v but got %v", NoneAction, action)
+	}
+	obj.StorageClass = ""
+	if action := lc.ComputeAction(obj); action != TransitionVersionAction {
+		t.Fatalf("Expected %v but got %v", TransitionVersionAction, action)
+	}
+}
+
+func TestLifecycleSort(t *testing.T) {
+	newRule := func(id, prefix string) Rule {
+		return Rule{
+			ID:         id,
+			Status:     Enabled,
+			Filter:     Filter{Prefix: Prefix{string: prefix, set: true}},
+			Expiration: Expiration{Days: ExpirationDays(3), set: true},
+		}
+	}
+	lc := Lifecycle{Rules: []Rule{
+		newRule("", "1"),
+		newRule("c", "2"),
+		newRule("a", "3"),
+		newRule("", "4"),
+		newRule("b", "5"),
+		newRule("a", "6"),
+	}}
+	order := func(lc Lifecycle) string {
+		var parts []string
+		for _, r := range lc.Rules {
+			parts = append(parts, r.ID+":"+r.GetPrefix())
+		}
+		return strings.Join(parts, ",")
+	}
+
+	expected := "a:3,a:6,b:5,c:2,:1,:4"
+	lc.Sort()
+	if got := order(lc); got != expected {
+		t.Fatalf("Expected %s but got %s", expected, got)
+	}
+	lc.Sort()
+	if got := order(lc); got != expected {
+		t.Fatalf("Expected sorting twice to give %s but got %s", expected, got)
+	}
+}
+
+func TestLifecycleValidateAll(t *testing.T) {
+	inputConfig := `<LifecycleConfiguration>
+		<Rule><ID>1</ID><Status>Unknown</Status><Filter><Prefix>a/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+		<Rule><ID>2</ID><Status>Enabled</Status><Filter><Prefix>b/</Prefix></Filter><Transition><Days>30</Days></Transition></Rule>
+		<Rule><ID>1</ID><Status>Enabled</Status><Filter><Prefix>c/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+		<Rule><ID>4</ID><Status>Enabled</Status><Filter><Prefix>d/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+	</LifecycleConfiguration>`
+	lc, err := ParseLifecycleConfig(strings.NewReader(inputConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		path string
+		err  error
+	}{
+		{path: "Rule[0].Status", err: errInvalidRuleStatus},
+		{path: "Rule[1].Transition[0].StorageClass", err: errXMLNotWellFormed},
+		{path: "Rule[2].ID", err: errLifecycleDuplicateID},
+	}
+	errs := lc.ValidateAll()
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors but got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, e := range expected {
+		var ve ValidationError
+		if !errors.As(errs[i], &ve) || ve.Path() != e.path || !errors.Is(errs[i], e.err) {
+			t.Fatalf("%d: Expected %s: %v but got %v", i+1, e.path, e.err, errs[i])
+		}
+	}
+
+	// Validate still reports the first error only
+	if err = lc.Validate(); err.Error() != errs[0].Error() {
+		t.Fatalf("Expected %v but got %v", errs[0], err)
+	}
+
+	// Several errors within a single rule
+	var rule Rule
+	if err = xml.Unmarshal([]byte(`<Rule><ID>x</ID><Status></Status><Filter><And><Prefix>x</Prefix></And></Filter></Rule>`), &rule); err != nil {
+		t.Fatal(err)
+	}
+	if errs = rule.ValidateAll(); len(errs) != 3 || !errors.Is(errs[0], errEmptyRuleStatus) ||
+		!errors.Is(errs[1], errXMLNotWellFormed) || !errors.Is(errs[2], errRuleNoAction) {
+		t.Fatalf("Expected status, filter and action errors but got %v", errs)
+	}
+
+	if errs = (Lifecycle{}).ValidateAll(); len(errs) != 0 {
+		t.Fatalf("Expected no errors but got %v", errs)
+	}
+}
+
+func TestLifecycleDuplicateFilters(t *testing.T) {
+	inputConfig := `<LifecycleConfiguration>
+		<Rule><ID>expire</ID><Status>Enabled</Status><Filter><And><Prefix>logs/</Prefix><Tag><Key>k1</Key><Value>v1</Value></Tag><Tag><Key>k2</Key><Value>v2</Value></Tag></And></Filter><Expiration><Days>30</Days></Expiration></Rule>
+		<Rule><ID>tmp</ID><Status>Enabled</Status><Filter><Prefix>tmp/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+		<Rule><ID>archive</ID><Status>Enabled</Status><Filter><And><Tag><Key>k2</Key><Value>v2</Value></Tag><Tag><Key>k1</Key><Value>v1</Value></Tag><Prefix>logs/</Prefix></And></Filter><Transition><Days>10</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>
+		<Rule><ID>legacy</ID><Status>Enabled</Status><Prefix>tmp/</Prefix><Expiration><Days>2</Days></Expiration></Rule>
+		<Rule><ID>other</ID><Status>Enabled</Status><Filter><Prefix>other/</Prefix></Filter><Expiration><Days>2</Days></Expiration></Rule>
+	</LifecycleConfiguration>`
+	lc, err := ParseLifecycleConfig(strings.NewReader(inputConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]int{{0, 2}, {1, 3}}
+	if got := lc.DuplicateFilters(); fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("Expected %v but got %v", expected, got)
+	}
+
+	lc.Rules = lc.Rules[:2]
+	if got := lc.DuplicateFilters(); got != nil {
+		t.Fatalf("Expected no duplicates but got %v", got)
+	}
+}
+
+func TestParseLifecycleConfigFlexible(t *testing.T) {
+	rules := `<Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule>` +
+		`<Rule><ID>archive</ID><Status>Enabled</Status><Filter><Prefix>data/</Prefix></Filter><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>`
+	testCases := []string{
+		`<BucketLifecycle>` + rules + `</BucketLifecycle>`,
+		`<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` + rules + `</LifecycleConfiguration>`,
+	}
+	for i, input := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			lc, err := ParseLifecycleConfigFlexible(strings.NewReader(input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = lc.Validate(); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			if len(lc.Rules) != 2 || lc.Rules[0].ID != "rule" || lc.Rules[1].ID != "archive" {
+				t.Fatalf("%d: Unexpected rules %v", i+1, lc)
+			}
+			// Written back with the standard root element
+			var buf bytes.Buffer
+			if _, err = lc.WriteTo(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(buf.String(), "<LifecycleConfiguration>") {
+				t.Fatalf("%d: Expected a LifecycleConfiguration but got %s", i+1, buf.String())
+			}
+		})
+	}
+
+	// The standard parser stays strict about the root element
+	if _, err := ParseLifecycleConfig(strings.NewReader(testCases[0])); err == nil {
+		t.Fatal("Expected an error parsing BucketLifecycle")
+	}
+	if _, err := ParseLifecycleConfigFlexible(strings.NewReader(`<BucketLifecycle><Rule>`)); err == nil {
+		t.Fatal("Expected an error parsing truncated input")
+	}
+}
+
+func TestLifecyclePendingActions(t *testing.T) {
+	lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(`<LifecycleConfiguration><Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix></Prefix></Filter><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition><Expiration><Days>90</Days></Expiration></Rule></LifecycleConfiguration>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2020, time.May, 21, 13, 42, 50, 0, time.UTC)
+	transition := PendingAction{RuleID: "rule", Action: TransitionAction, StorageClass: "GLACIER", Time: time.Date(2020, time.June, 21, 0, 0, 0, 0, time.UTC)}
+	expiration := PendingAction{RuleID: "rule", Action: DeleteAction, Time: time.Date(2020, time.August, 20, 0, 0, 0, 0, time.UTC)}
+
+	testCases := []struct {
+		obj      ObjectOpts
+		now      time.Time
+		expected []PendingAction
+	}{
+		{
+			obj:      ObjectOpts{Name: "obj", ModTime: modTime},
+			now:      modTime.Add(24 * time.Hour),
+			expected: []PendingAction{transition, expiration},
+		},
+		{ // The transition already took place
+			obj:      ObjectOpts{Name: "obj", ModTime: modTime},
+			now:      transition.Time,
+			expected: []PendingAction{expiration},
+		},
+		{ // Already in the target storage class
+			obj:      ObjectOpts{Name: "obj", ModTime: modTime, StorageClass: "GLACIER"},
+			now:      modTime,
+			expected: []PendingAction{expiration},
+		},
+		{
+			obj: ObjectOpts{Name: "obj", ModTime: modTime},
+			now: expiration.Time.Add(time.Second),
+		},
+		{ // Noncurrent versions are not affected by current version actions
+			obj: ObjectOpts{Name: "obj", ModTime: modTime, VersionID: "v1", SuccessorModTime: modTime.Add(time.Hour)},
+			now: modTime,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			got := lc.PendingActions(tc.obj, tc.now)
+			if len(got) != len(tc.expected) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected, got)
+			}
+			for j := range got {
+				if got[j] != tc.expected[j] {
+					t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected[j], got[j])
+				}
+			}
+		})
+	}
+}
+
+func TestParseLifecycleConfigLenient(t *testing.T) {
+	input := `<LifecycleConfiguration><Rule><ID>rule</ID><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule>` +
+		`<Rule><ID>disabled</ID><Status>Disabled</Status><Filter><Prefix>data/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule></LifecycleConfiguration>`
+
+	// Strict parsing keeps the empty Status, which fails validation
+	lc, err := ParseLifecycleConfig(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = lc.Validate(); !errors.Is(err, errEmptyRuleStatus) {
+		t.Fatalf("Expected %v but got %v", errEmptyRuleStatus, err)
+	}
+
+	lc, err = ParseLifecycleConfigLenient(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = lc.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if lc.Rules[0].Status != Enabled || lc.Rules[1].Status != Disabled {
+		t.Fatalf("Expected Enabled and Disabled but got %s and %s", lc.Rules[0].Status, lc.Rules[1].Status)
+	}
+
+	// The defaulted Status is written back explicitly
+	var buf bytes.Buffer
+	if _, err = lc.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<ID>rule</ID><Status>Enabled</Status>") {
+		t.Fatalf("Expected an explicit Status but got %s", buf.String())
+	}
+
+	if _, err = ParseLifecycleConfigLenient(strings.NewReader(`<LifecycleConfiguration><Rule>`)); err == nil {
+		t.Fatal("Expected an error parsing truncated input")
+	}
+}
+
+func TestValidateBatch(t *testing.T) {
+	valid := Lifecycle{Rules: []Rule{{
+		ID:         "rule",
+		Status:     Enabled,
+		Filter:     Filter{Prefix: Prefix{string: "logs/", set: true}},
+		Expiration: Expiration{Days: ExpirationDays(3), set: true},
+	}}}
+	noStatus := Lifecycle{Rules: []Rule{valid.Rules[0]}}
+	noStatus.Rules[0].Status = ""
+	duplicateID := Lifecycle{Rules: []Rule{valid.Rules[0], valid.Rules[0]}}
+
+	var configs []Lifecycle
+	var expected []error
+	for i := 0; i < 100; i++ {
+		switch i % 4 {
+		case 0:
+			configs, expected = append(configs, Lifecycle{}), append(expected, nil)
+		case 1:
+			configs, expected = append(configs, noStatus), append(expected, errEmptyRuleStatus)
+		case 2:
+			configs, expected = append(configs, duplicateID), append(expected, errLifecycleDuplicateID)
+		default:
+			configs, expected = append(configs, valid), append(expected, nil)
+		}
+	}
+
+	errs := ValidateBatch(configs)
+	if len(errs) != len(configs) {
+		t.Fatalf("Expected %d results but got %d", len(configs), len(errs))
+	}
+	for i, err := range errs {
+		if expected[i] == nil {
+			if err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i, err)
+			}
+			continue
+		}
+		if !errors.Is(err, expected[i]) {
+			t.Fatalf("%d: Expected %v but got %v", i, expected[i], err)
+		}
+	}
+
+	if errs = ValidateBatch(nil); len(errs) != 0 {
+		t.Fatalf("Expected no results but got %v", errs)
+	}
+}
+
+func TestStrictDisabledRules(t *testing.T) {
+	lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(`<LifecycleConfiguration>` +
+		`<Rule><ID>logs</ID><Status>Disabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule>` +
+		`<Rule><ID>data</ID><Status>Enabled</Status><Filter><Prefix>data/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule>` +
+		`</LifecycleConfiguration>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().UTC().Add(-7 * 24 * time.Hour)
+	recent := time.Now().UTC()
+
+	testCases := []struct {
+		strict         bool
+		obj            ObjectOpts
+		expectedAction Action
+		expectedErr    error
+	}{
+		{ // Disabled rules are skipped by default
+			obj:            ObjectOpts{Name: "logs/obj", ModTime: old},
+			expectedAction: NoneAction,
+		},
+		{
+			strict:      true,
+			obj:         ObjectOpts{Name: "logs/obj", ModTime: old},
+			expectedErr: errLifecycleDisabledRuleMatch,
+		},
+		{ // The Disabled rule would have no action due yet
+			strict:         true,
+			obj:            ObjectOpts{Name: "logs/obj", ModTime: recent},
+			expectedAction: NoneAction,
+		},
+		{ // The Disabled rule does not match the object
+			strict:         true,
+			obj:            ObjectOpts{Name: "data/obj", ModTime: old},
+			expectedAction: DeleteAction,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			obj := tc.obj
+			obj.StrictDisabledRules = tc.strict
+			action, err := lc.ComputeActionContext(context.Background(), obj)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if action != tc.expectedAction {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedAction, action)
+			}
+			// Strict mode is ignored when the error can't be reported.
+			if got, want := lc.ComputeAction(obj), lc.ComputeAction(tc.obj); got != want {
+				t.Fatalf("%d: Expected %v but got %v", i+1, want, got)
+			}
+		})
+	}
+}
+
+func TestLifecycleEnableDisableRule(t *testing.T) {
+	lc := Lifecycle{}
+	for i := 0; i < 2; i++ {
+		lc.Rules = append(lc.Rules, Rule{
+			ID:         fmt.Sprintf("rule-%d", i),
+			Status:     Enabled,
+			Filter:     Filter{Prefix: Prefix{string: fmt.Sprintf("prefix-%d", i), set: true}},
+			Expiration: Expiration{Days: ExpirationDays(3), set: true},
+		})
+	}
+
+	if err := lc.DisableRule("rule-1"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if lc.Rules[0].Status != Enabled || lc.Rules[1].Status != Disabled {
+		t.Fatalf("Expected Enabled and Disabled but got %s and %s", lc.Rules[0].Status, lc.Rules[1].Status)
+	}
+	if err := lc.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	// Disabling twice is fine
+	if err := lc.DisableRule("rule-1"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if err := lc.EnableRule("rule-1"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if lc.Rules[1].Status != Enabled {
+		t.Fatalf("Expected Enabled but got %s", lc.Rules[1].Status)
+	}
+
+	if err := lc.EnableRule("rule-2"); !errors.Is(err, errLifecycleRuleNotFound) {
+		t.Fatalf("Expected %v but got %v", errLifecycleRuleNotFound, err)
+	}
+	if err := lc.DisableRule(""); !errors.Is(err, errLifecycleRuleNotFound) {
+		t.Fatalf("Expected %v but got %v", errLifecycleRuleNotFound, err)
+	}
+}
+
+func TestPlaceholdersNotTransitioned(t *testing.T) {
+	lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(`<LifecycleConfiguration><Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix></Prefix></Filter>` +
+		`<Transition><Days>1</Days><StorageClass>GLACIER</StorageClass></Transition>` +
+		`<Expiration><ExpiredObjectDeleteMarker>true</ExpiredObjectDeleteMarker></Expiration>` +
+		`<NoncurrentVersionTransition><NoncurrentDays>1</NoncurrentDays><StorageClass>GLACIER</StorageClass></NoncurrentVersionTransition>` +
+		`</Rule></LifecycleConfiguration>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().UTC().Add(-7 * 24 * time.Hour)
+
+	testCases := []struct {
+		obj            ObjectOpts
+		expectedAction Action
+	}{
+		{ // The only remaining version is an expired delete marker
+			obj:            ObjectOpts{Name: "obj", ModTime: old, VersionID: "v1", IsLatest: true, DeleteMarker: true, NumVersions: 1},
+			expectedAction: DeleteVersionAction,
+		},
+		{
+			obj:            ObjectOpts{Name: "obj", ModTime: old, VersionID: "v2", IsLatest: true, DeleteMarker: true, NumVersions: 2},
+			expectedAction: NoneAction,
+		},
+		{
+			obj:            ObjectOpts{Name: "obj", ModTime: old, DeleteMarker: true},
+			expectedAction: NoneAction,
+		},
+		{
+			obj:            ObjectOpts{Name: "obj", ModTime: old, VersionID: "v1", DeleteMarker: true, SuccessorModTime: old},
+			expectedAction: NoneAction,
+		},
+		{ // Directory placeholder
+			obj:            ObjectOpts{Name: "dir/", ModTime: old},
+			expectedAction: NoneAction,
+		},
+		{
+			obj:            ObjectOpts{Name: "dir/", ModTime: old, Size: 1},
+			expectedAction: TransitionAction,
+		},
+		{
+			obj:            ObjectOpts{Name: "obj", ModTime: old},
+			expectedAction: TransitionAction,
+		},
+		{
+			obj:            ObjectOpts{Name: "obj", ModTime: old, VersionID: "v1", SuccessorModTime: old},
+			expectedAction: TransitionVersionAction,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if got := lc.ComputeAction(tc.obj); got != tc.expectedAction {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedAction, got)
+			}
+		})
+	}
+}
+
+func TestLifecycleMarshalCanonicalJSON(t *testing.T) {
+	rule1 := `<Rule><ID>logs</ID><Status>Enabled</Status><Filter><And><Prefix>logs/</Prefix><Tag><Key>k1</Key><Value>v1</Value></Tag><Tag><Key>k2</Key><Value>v2</Value></Tag></And></Filter>` +
+		`<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition><Expiration><Days>90</Days></Expiration></Rule>`
+	rule2 := `<Rule><ID>data</ID><Status>Disabled</Status><Filter><Prefix>data/</Prefix></Filter><NoncurrentVersionExpiration><NoncurrentDays>7</NoncurrentDays></NoncurrentVersionExpiration></Rule>`
+	parse := func(s string) Lifecycle {
+		lc, err := ParseLifecycleConfig(strings.NewReader(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *lc
+	}
+	a := parse(`<LifecycleConfiguration>` + rule1 + rule2 + `</LifecycleConfiguration>`)
+	b := parse(`<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` + rule2 + rule1 + `</LifecycleConfiguration>`)
+	a.Sort()
+	b.Sort()
+
+	ja, err := a.MarshalCanonicalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jb, err := b.MarshalCanonicalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ja, jb) {
+		t.Fatalf("Expected\n%s\nbut got\n%s", ja, jb)
+	}
+
+	expected := `{"Rule":[` +
+		`{"Filter":{"Prefix":"data/"},"ID":"data","NoncurrentVersionExpiration":{"NoncurrentDays":"7"},"Status":"Disabled"},` +
+		`{"Expiration":{"Days":"90"},"Filter":{"And":{"Prefix":"logs/","Tag":[{"Key":"k1","Value":"v1"},{"Key":"k2","Value":"v2"}]}},"ID":"logs","Status":"Enabled","Transition":[{"Days":"30","StorageClass":"GLACIER"}]}` +
+		`]}`
+	if string(ja) != expected {
+		t.Fatalf("Expected\n%s\nbut got\n%s", expected, ja)
+	}
+
+	// A different configuration encodes differently
+	b.Rules[0].Status = Enabled
+	if jb, err = b.MarshalCanonicalJSON(); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(ja, jb) {
+		t.Fatal("Expected different encodings")
+	}
+
+	if j, err := (Lifecycle{}).MarshalCanonicalJSON(); err != nil || string(j) != "{}" {
+		t.Fatalf("Expected {} but got %s, %v", j, err)
+	}
+}
+
+func TestTransitionFromBecameCurrentTime(t *testing.T) {
+	lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(`<LifecycleConfiguration><Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix></Prefix></Filter>` +
+		`<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition><Expiration><Days>90</Days></Expiration></Rule></LifecycleConfiguration>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	created := now.Add(-60 * 24 * time.Hour)
+
+	testCases := []struct {
+		obj            ObjectOpts
+		expectedAction Action
+	}{
+		{
+			obj:            ObjectOpts{Name: "obj", ModTime: created, VersionID: "v1", IsLatest: true},
+			expectedAction: TransitionAction,
+		},
+		{ // Became current 10 days ago, the transition is not due yet
+			obj:            ObjectOpts{Name: "obj", ModTime: created, VersionID: "v1", IsLatest: true, BecameCurrentTime: now.Add(-10 * 24 * time.Hour)},
+			expectedAction: NoneAction,
+		},
+		{
+			obj:            ObjectOpts{Name: "obj", ModTime: created, VersionID: "v1", IsLatest: true, BecameCurrentTime: now.Add(-40 * 24 * time.Hour)},
+			expectedAction: TransitionAction,
+		},
+		{ // Only transitions are timed from BecameCurrentTime
+			obj:            ObjectOpts{Name: "obj", ModTime: now.Add(-100 * 24 * time.Hour), VersionID: "v1", IsLatest: true, BecameCurrentTime: now.Add(-10 * 24 * time.Hour)},
+			expectedAction: DeleteAction,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if got := lc.ComputeAction(tc.obj); got != tc.expectedAction {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedAction, got)
+			}
+		})
+	}
+
+	// The pending transition is scheduled from BecameCurrentTime too
+	becameCurrent := time.Date(2020, time.May, 21, 13, 42, 50, 0, time.UTC)
+	pending := lc.PendingActions(ObjectOpts{Name: "obj", ModTime: becameCurrent.AddDate(0, -1, 0), BecameCurrentTime: becameCurrent}, becameCurrent)
+	if expected := ExpectedExpiryTime(becameCurrent, 30); len(pending) == 0 || pending[0].Action != TransitionAction || !pending[0].Time.Equal(expected) {
+		t.Fatalf("Expected a transition at %v but got %v", expected, pending)
+	}
+}
+
+func TestLifecycleRuleIDLength(t *testing.T) {
+	newLifecycle := func(id string) Lifecycle {
+		return Lifecycle{Rules: []Rule{
+			{ID: "first", Status: Enabled, Filter: Filter{Prefix: Prefix{string: "a/", set: true}}, Expiration: Expiration{Days: ExpirationDays(3), set: true}},
+			{ID: id, Status: Enabled, Filter: Filter{Prefix: Prefix{string: "b/", set: true}}, Expiration: Expiration{Days: ExpirationDays(3), set: true}},
+		}}
+	}
+	testCases := []struct {
+		id          string
+		expectedErr error
+	}{
+		{strings.Repeat("a", 255), nil},
+		{strings.Repeat("a", 256), errInvalidRuleID},
+		// The limit applies to bytes, not runes
+		{strings.Repeat("é", 128), errInvalidRuleID},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			err := newLifecycle(tc.id).Validate()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if tc.expectedErr == nil {
+				return
+			}
+			var ve ValidationError
+			if !errors.As(err, &ve) || ve.Path() != "Rule[1].ID" {
+				t.Fatalf("%d: Expected the error on Rule[1].ID but got %v", i+1, err)
+			}
+			if !strings.Contains(err.Error(), "got 256 bytes") {
+				t.Fatalf("%d: Expected the ID length in %q", i+1, err)
+			}
+		})
+	}
+}
+
+func TestLifecycleFillMissingIDs(t *testing.T) {
+	input := `<LifecycleConfiguration>` +
+		`<Rule><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule>` +
+		`<Rule><ID>named</ID><Status>Enabled</Status><Filter><Prefix>data/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule>` +
+		`<Rule><Status>Enabled</Status><Filter><Prefix>tmp/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>` +
+		`<Rule><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule>` +
+		`</LifecycleConfiguration>`
+	parse := func() *Lifecycle {
+		lc, err := ParseLifecycleConfig(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lc
+	}
+
+	first, second := parse(), parse()
+	if n := first.FillMissingIDs(); n != 3 {
+		t.Fatalf("Expected 3 IDs assigned but got %d", n)
+	}
+	second.FillMissingIDs()
+	for i := range first.Rules {
+		if first.Rules[i].ID == "" || first.Rules[i].ID != second.Rules[i].ID {
+			t.Fatalf("%d: Expected identical generated IDs but got %q and %q", i, first.Rules[i].ID, second.Rules[i].ID)
+		}
+	}
+	if first.Rules[1].ID != "named" {
+		t.Fatalf("Expected the existing ID to be kept but got %s", first.Rules[1].ID)
+	}
+	if first.Rules[0].ID == first.Rules[2].ID {
+		t.Fatalf("Expected different rules to get different IDs but got %s", first.Rules[0].ID)
+	}
+	// Identical rules get suffixed IDs
+	if first.Rules[3].ID != first.Rules[0].ID+"-1" {
+		t.Fatalf("Expected %s-1 but got %s", first.Rules[0].ID, first.Rules[3].ID)
+	}
+	if err := first.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if n := first.FillMissingIDs(); n != 0 {
+		t.Fatalf("Expected no ID assigned but got %d", n)
+	}
+}
+
+func TestParseLifecycleConfigLimited(t *testing.T) {
+	input := `<LifecycleConfiguration><Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule></LifecycleConfiguration>`
+	size := int64(len(input))
+
+	lc, err := ParseLifecycleConfigLimited(strings.NewReader(input), size)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(lc.Rules) != 1 || lc.Rules[0].ID != "rule" {
+		t.Fatalf("Unexpected configuration %v", lc)
+	}
+
+	// Just over the limit
+	if _, err = ParseLifecycleConfigLimited(strings.NewReader(input), size-1); !errors.Is(err, errLifecycleConfigTooLarge) {
+		t.Fatalf("Expected %v but got %v", errLifecycleConfigTooLarge, err)
+	}
+	// Trailing whitespace counts too
+	if _, err = ParseLifecycleConfigLimited(strings.NewReader(input+strings.Repeat(" ", 1<<20)), size+10); !errors.Is(err, errLifecycleConfigTooLarge) {
+		t.Fatalf("Expected %v but got %v", errLifecycleConfigTooLarge, err)
+	}
+	// Other errors are returned as is within the limit
+	if _, err = ParseLifecycleConfigLimited(strings.NewReader(input[:size-1]), size); err == nil || errors.Is(err, errLifecycleConfigTooLarge) {
+		t.Fatalf("Expected a parse error but got %v", err)
+	}
+	// No limit at all
+	if lc, err = ParseLifecycleConfigLimited(strings.NewReader(input), math.MaxInt64); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(lc.Rules) != 1 || lc.Rules[0].ID != "rule" {
+		t.Fatalf("Unexpected configuration %v", lc)
+	}
+}
+
+func TestComputeTransitionOnly(t *testing.T) {
+	lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(`<LifecycleConfiguration>` +
+		`<Rule><ID>expire</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>10</Days></Expiration>` +
+		`<NoncurrentVersionExpiration><NoncurrentDays>1</NoncurrentDays></NoncurrentVersionExpiration></Rule>` +
+		`<Rule><ID>archive</ID><Status>Enabled</Status><Filter><Prefix>data/</Prefix></Filter><Expiration><Days>10</Days></Expiration>` +
+		`<Transition><Days>10</Days><StorageClass>GLACIER</StorageClass></Transition>` +
+		`<NoncurrentVersionTransition><NoncurrentDays>1</NoncurrentDays><StorageClass>GLACIER</StorageClass></NoncurrentVersionTransition></Rule>` +
+		`</LifecycleConfiguration>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().UTC().Add(-30 * 24 * time.Hour)
+
+	testCases := []struct {
+		obj            ObjectOpts
+		expectedAction Action
+		expectedRuleID string
+	}{
+		{ // The expiration only rule is ignored
+			obj:            ObjectOpts{Name: "logs/obj", ModTime: old},
+			expectedAction: NoneAction,
+		},
+		{
+			obj:            ObjectOpts{Name: "logs/obj", ModTime: old, VersionID: "v1", SuccessorModTime: old},
+			expectedAction: NoneAction,
+		},
+		{ // Expiration, due on the same day, is ignored
+			obj:            ObjectOpts{Name: "data/obj", ModTime: old},
+			expectedAction: TransitionAction,
+			expectedRuleID: "archive",
+		},
+		{
+			obj:            ObjectOpts{Name: "data/obj", ModTime: old, VersionID: "v1", SuccessorModTime: old},
+			expectedAction: TransitionVersionAction,
+			expectedRuleID: "archive",
+		},
+		{ // An expired restored copy is not deleted
+			obj:            ObjectOpts{Name: "data/obj", ModTime: old, TransitionStatus: TransitionComplete, RestoreExpires: old},
+			expectedAction: NoneAction,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if got := lc.ComputeAction(tc.obj); tc.expectedAction == TransitionAction && got != DeleteAction {
+				t.Fatalf("%d: Expected ComputeAction to expire the object but got %v", i+1, got)
+			}
+			got := lc.ComputeTransitionOnly(tc.obj)
+			if got.Action != tc.expectedAction || got.RuleID != tc.expectedRuleID {
+				t.Fatalf("%d: Expected %v from %q but got %v from %q", i+1, tc.expectedAction, tc.expectedRuleID, got.Action, got.RuleID)
+			}
+		})
+	}
+}
+
+func TestLifecycleDeduplicateRules(t *testing.T) {
+	testCases := []struct {
+		inputConfig   string
+		expectedRules []string
+		expectedErr   error
+	}{
+		{ // Complementary actions of rules with the same filter are combined
+			inputConfig: `<LifecycleConfiguration>` +
+				`<Rule><ID>expire</ID><Status>Enabled</Status><Filter><And><Prefix>data/</Prefix><Tag><Key>k1</Key><Value>v1</Value></Tag><Tag><Key>k2</Key><Value>v2</Value></Tag></And></Filter><Expiration><Days>90</Days></Expiration></Rule>` +
+				`<Rule><ID>other</ID><Status>Enabled</Status><Filter><Prefix>tmp/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>` +
+				`<Rule><ID>archive</ID><Status>Enabled</Status><Filter><And><Tag><Key>k2</Key><Value>v2</Value></Tag><Tag><Key>k1</Key><Value>v1</Value></Tag><Prefix>data/</Prefix></And></Filter><Transition><Days>60</Days><StorageClass>COLD</StorageClass></Transition></Rule>` +
+				`<Rule><ID>warm</ID><Status>Enabled</Status><Filter><And><Prefix>data/</Prefix><Tag><Key>k1</Key><Value>v1</Value></Tag><Tag><Key>k2</Key><Value>v2</Value></Tag></And></Filter><Transition><Days>30</Days><StorageClass>WARM</StorageClass></Transition><Expiration><Days>90</Days></Expiration></Rule>` +
+				`</LifecycleConfiguration>`,
+			expectedRules: []string{
+				`<Rule><ID>expire</ID><Status>Enabled</Status><Filter><And><Prefix>data/</Prefix><Tag><Key>k1</Key><Value>v1</Value></Tag><Tag><Key>k2</Key><Value>v2</Value></Tag></And></Filter><Expiration><Days>90</Days></Expiration><Transition><Days>30</Days><StorageClass>WARM</StorageClass></Transition><Transition><Days>60</Days><StorageClass>COLD</StorageClass></Transition></Rule>`,
+				`<Rule><ID>other</ID><Status>Enabled</Status><Filter><Prefix>tmp/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>`,
+			},
+		},
+		{ // Rules with a different Status are kept apart
+			inputConfig: `<LifecycleConfiguration>` +
+				`<Rule><ID>a</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>` +
+				`<Rule><ID>b</ID><Status>Disabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>2</Days></Expiration></Rule>` +
+				`</LifecycleConfiguration>`,
+			expectedRules: []string{
+				`<Rule><ID>a</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>`,
+				`<Rule><ID>b</ID><Status>Disabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>2</Days></Expiration></Rule>`,
+			},
+		},
+		{ // Conflicting expirations
+			inputConfig: `<LifecycleConfiguration>` +
+				`<Rule><ID>a</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>` +
+				`<Rule><ID>b</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>2</Days></Expiration></Rule>` +
+				`</LifecycleConfiguration>`,
+			expectedErr: errLifecycleConflictingRules,
+		},
+		{ // Transitions to the same storage class at different times
+			inputConfig: `<LifecycleConfiguration>` +
+				`<Rule><ID>a</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Transition><Days>10</Days><StorageClass>WARM</StorageClass></Transition></Rule>` +
+				`<Rule><ID>b</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Transition><Days>20</Days><StorageClass>WARM</StorageClass></Transition></Rule>` +
+				`</LifecycleConfiguration>`,
+			expectedErr: errLifecycleConflictingRules,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(tc.inputConfig)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			numRules := len(lc.Rules)
+			removed, err := lc.DeduplicateRules()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if err != nil {
+				if len(lc.Rules) != numRules {
+					t.Fatalf("%d: Expected the rules to be left unchanged", i+1)
+				}
+				return
+			}
+			if removed != numRules-len(tc.expectedRules) {
+				t.Fatalf("%d: Expected %d rules removed but got %d", i+1, numRules-len(tc.expectedRules), removed)
+			}
+			var got []string
+			for _, r := range lc.Rules {
+				b, err := xml.Marshal(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, string(b))
+			}
+			if !reflect.DeepEqual(got, tc.expectedRules) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedRules, got)
+			}
+			if err = lc.Validate(); err != nil {
+				t.Fatalf("%d: Expected a valid configuration but got %v", i+1, err)
+			}
+		})
+	}
+}
+
+func TestParseLifecycleConfigWithWarnings(t *testing.T) {
+	testCases := []struct {
+		inputConfig      string
+		expectedWarnings []string
+	}{
+		{
+			inputConfig:      `<LifecycleConfiguration><Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule></LifecycleConfiguration>`,
+			expectedWarnings: nil,
+		},
+		{
+			inputConfig: `<LifecycleConfiguration>` +
+				`<Rule><ID>current</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule>` +
+				`<Rule><ID>legacy</ID><Status>Enabled</Status><Prefix>tmp/</Prefix><Expiration><Days>Indefinite</Days></Expiration>` +
+				`<Transition><Days> indefinite </Days><StorageClass>WARM</StorageClass></Transition></Rule>` +
+				`</LifecycleConfiguration>`,
+			expectedWarnings: []string{
+				"Rule[1].Prefix: Prefix is deprecated, use Filter instead",
+				"Rule[1].Expiration.Days: Indefinite is deprecated, omit Days instead",
+				"Rule[1].Transition.Days: Indefinite is deprecated, omit Days instead",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			lc, w, err := ParseLifecycleConfigWithWarnings(strings.NewReader(tc.inputConfig))
+			if err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			if !reflect.DeepEqual(w.Warnings, tc.expectedWarnings) {
+				t.Fatalf("%d: Expected %q but got %q", i+1, tc.expectedWarnings, w.Warnings)
+			}
+			// The configuration is parsed as by ParseLifecycleConfig
+			expected, err := ParseLifecycleConfig(strings.NewReader(tc.inputConfig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lc.String() != expected.String() {
+				t.Fatalf("%d: Expected %s but got %s", i+1, expected, lc)
+			}
+		})
+	}
+
+	if _, _, err := ParseLifecycleConfigWithWarnings(strings.NewReader(`<LifecycleConfiguration><Rule>`)); err == nil {
+		t.Fatal("Expected an error for a truncated configuration")
+	}
+}
+
+func TestLifecycleFreezeToDate(t *testing.T) {
+	inputConfig := `<LifecycleConfiguration>` +
+		`<Rule><ID>days</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>90</Days></Expiration>` +
+		`<Transition><Days>30</Days><StorageClass>WARM</StorageClass></Transition><Transition><Days>60</Days><StorageClass>COLD</StorageClass></Transition>` +
+		`<NoncurrentVersionExpiration><NoncurrentDays>7</NoncurrentDays></NoncurrentVersionExpiration></Rule>` +
+		`<Rule><ID>date</ID><Status>Enabled</Status><Filter><Prefix>tmp/</Prefix></Filter><Expiration><Date>2021-01-01T00:00:00Z</Date></Expiration></Rule>` +
+		`</LifecycleConfiguration>`
+	lc, err := ParseLifecycleConfig(strings.NewReader(inputConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2020, time.May, 21, 13, 42, 50, 0, time.UTC)
+
+	frozen := lc.FreezeToDate(base)
+	var buf bytes.Buffer
+	if _, err = frozen.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `<LifecycleConfiguration>` +
+		`<Rule><ID>days</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Date>2020-08-20T00:00:00Z</Date></Expiration>` +
+		`<Transition><Date>2020-06-21T00:00:00Z</Date><StorageClass>WARM</StorageClass></Transition><Transition><Date>2020-07-21T00:00:00Z</Date><StorageClass>COLD</StorageClass></Transition>` +
+		`<NoncurrentVersionExpiration><NoncurrentDays>7</NoncurrentDays></NoncurrentVersionExpiration></Rule>` +
+		`<Rule><ID>date</ID><Status>Enabled</Status><Filter><Prefix>tmp/</Prefix></Filter><Expiration><Date>2021-01-01T00:00:00Z</Date></Expiration></Rule>` +
+		`</LifecycleConfiguration>`
+	if buf.String() != expected {
+		t.Fatalf("Expected %s but got %s", expected, buf.String())
+	}
+	if err = frozen.Validate(); err != nil {
+		t.Fatalf("Expected a valid configuration but got %v", err)
+	}
+
+	// The original configuration is left untouched
+	if lc.Rules[0].Expiration.Days != 90 || lc.Rules[0].Transitions[0].Days != 30 || !lc.Rules[0].Transitions[0].IsDateNull() {
+		t.Fatalf("Expected the original configuration to be unchanged but got %v", lc.Rules[0])
+	}
+}
+
+func TestValidateReservedPrefixes(t *testing.T) {
+	reserved := []string{".minio.sys/", "internal/meta/"}
+	testCases := []struct {
+		inputConfig    string
+		expectedErrors []string
+	}{
+		{ // Safe prefixes
+			inputConfig: `<LifecycleConfiguration>
+				<Rule><ID>logs</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+				<Rule><ID>internal</ID><Status>Enabled</Status><Filter><Prefix>internal/data/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+			</LifecycleConfiguration>`,
+		},
+		{ // A prefix covering a reserved prefix, or under one
+			inputConfig: `<LifecycleConfiguration>
+				<Rule><ID>internal</ID><Status>Enabled</Status><Filter><Prefix>internal/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+				<Rule><ID>sys</ID><Status>Enabled</Status><Filter><And><Prefix>.minio.sys/tmp/</Prefix><Tag><Key>k</Key><Value>v</Value></Tag></And></Filter><Expiration><Days>1</Days></Expiration></Rule>
+			</LifecycleConfiguration>`,
+			expectedErrors: []string{
+				`Rule[0].Filter: Rule could apply to objects under a reserved prefix: "internal/meta/"`,
+				`Rule[1].Filter: Rule could apply to objects under a reserved prefix: ".minio.sys/"`,
+			},
+		},
+		{ // A rule without prefix applies to every object
+			inputConfig: `<LifecycleConfiguration>
+				<Rule><ID>all</ID><Status>Enabled</Status><Filter></Filter><Expiration><Days>1</Days></Expiration></Rule>
+			</LifecycleConfiguration>`,
+			expectedErrors: []string{
+				`Rule[0].Filter: Rule could apply to objects under a reserved prefix: ".minio.sys/"`,
+				`Rule[0].Filter: Rule could apply to objects under a reserved prefix: "internal/meta/"`,
+			},
+		},
+		{ // Disabled rules are ignored
+			inputConfig: `<LifecycleConfiguration>
+				<Rule><ID>all</ID><Status>Disabled</Status><Filter></Filter><Expiration><Days>1</Days></Expiration></Rule>
+			</LifecycleConfiguration>`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(tc.inputConfig)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs := lc.ValidateReservedPrefixes(reserved)
+			if len(errs) != len(tc.expectedErrors) {
+				t.Fatalf("%d: Expected %d errors but got %v", i+1, len(tc.expectedErrors), errs)
+			}
+			for j, err := range errs {
+				if !errors.Is(err, errLifecycleReservedPrefix) {
+					t.Fatalf("%d: Expected %v but got %v", i+1, errLifecycleReservedPrefix, err)
+				}
+				var ve ValidationError
+				if !errors.As(err, &ve) || ve.Detail() != tc.expectedErrors[j] {
+					t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedErrors[j], err)
+				}
+			}
+		})
+	}
+}
+
+func TestLifecycleMarshalEmpty(t *testing.T) {
+	expected := `<LifecycleConfiguration></LifecycleConfiguration>`
+	for i, lc := range []Lifecycle{{}, {Rules: []Rule{}}} {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if err := lc.Validate(); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			b, err := xml.Marshal(lc)
+			if err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			if string(b) != expected {
+				t.Fatalf("%d: Expected %s but got %s", i+1, expected, string(b))
+			}
+			// The empty configuration round-trips
+			parsed, err := ParseLifecycleConfig(bytes.NewReader(b))
+			if err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			if len(parsed.Rules) != 0 {
+				t.Fatalf("%d: Expected no rules but got %v", i+1, parsed.Rules)
+			}
+		})
+	}
+}
+
+func TestNewerNoncurrentVersions(t *testing.T) {
+	lc, err := ParseLifecycleConfig(bytes.NewReader([]byte(`<LifecycleConfiguration><Rule><ID>rule</ID><Status>Enabled</Status><Filter></Filter>` +
+		`<NoncurrentVersionExpiration><NoncurrentDays>1</NoncurrentDays><NewerNoncurrentVersions>2</NewerNoncurrentVersions></NoncurrentVersionExpiration>` +
+		`</Rule></LifecycleConfiguration>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().UTC().Add(-10 * 24 * time.Hour)
+
+	testCases := []struct {
+		number         int
+		expectedAction Action
+	}{
+		{ // Not known, kept
+			number:         0,
+			expectedAction: NoneAction,
+		},
+		{
+			number:         1,
+			expectedAction: NoneAction,
+		},
+		{
+			number:         2,
+			expectedAction: NoneAction,
+		},
+		{
+			number:         3,
+			expectedAction: DeleteVersionAction,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			obj := ObjectOpts{Name: "obj", ModTime: old, VersionID: "v1", SuccessorModTime: old, NumVersions: 4, NoncurrentVersionNumber: tc.number}
+			if got := lc.ComputeAction(obj); got != tc.expectedAction {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedAction, got)
+			}
+			if ruleID, _ := lc.PredictExpiryTime(obj); (ruleID != "") != (tc.expectedAction == DeleteVersionAction) {
+				t.Fatalf("%d: Unexpected predicted expiry by %q", i+1, ruleID)
+			}
+		})
+	}
+}
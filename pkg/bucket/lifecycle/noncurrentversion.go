@@ -20,16 +20,23 @@ import (
 	"encoding/xml"
 )
 
+var (
+	errNoncurrentInvalidNewerVersions = Errorf("NewerNoncurrentVersions must be 0 or greater when used with NoncurrentVersionExpiration")
+	errNoncurrentNewerVersionsNoDays  = Errorf("NewerNoncurrentVersions must be specified with NoncurrentDays in NoncurrentVersionExpiration")
+)
+
 // NoncurrentVersionExpiration - an action for lifecycle configuration rule.
 type NoncurrentVersionExpiration struct {
-	XMLName        xml.Name       `xml:"NoncurrentVersionExpiration"`
-	NoncurrentDays ExpirationDays `xml:"NoncurrentDays,omitempty"`
-	set            bool
+	XMLName                 xml.Name       `xml:"NoncurrentVersionExpiration"`
+	NoncurrentDays          ExpirationDays `xml:"NoncurrentDays,omitempty"`
+	NewerNoncurrentVersions int            `xml:"NewerNoncurrentVersions,omitempty"`
+	set                     bool
 }
 
-// MarshalXML if non-current days not set to non zero value
+// MarshalXML if non-current days or newer non-current versions
+// are not set to non zero value
 func (n NoncurrentVersionExpiration) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
-	if n.IsDaysNull() {
+	if n.IsDaysNull() && n.NewerNoncurrentVersions == 0 {
 		return nil
 	}
 	type noncurrentVersionExpirationWrapper NoncurrentVersionExpiration
@@ -59,8 +66,15 @@ func (n NoncurrentVersionExpiration) Validate() error {
 	if !n.set {
 		return nil
 	}
-	val := int(n.NoncurrentDays)
-	if val <= 0 {
+	if n.NewerNoncurrentVersions < 0 {
+		return errNoncurrentInvalidNewerVersions
+	}
+	if int(n.NoncurrentDays) <= 0 {
+		// As in AWS S3, NewerNoncurrentVersions only
+		// qualifies NoncurrentDays.
+		if n.NewerNoncurrentVersions > 0 {
+			return errNoncurrentNewerVersionsNoDays
+		}
 		return errXMLNotWellFormed
 	}
 	return nil
@@ -0,0 +1,73 @@
+/*
+ * MinIO Cloud Storage, (C) 2021 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lifecycle
+
+import (
+	"encoding/xml"
+	"fmt"
+	"testing"
+)
+
+func TestNoncurrentVersionExpiration(t *testing.T) {
+	testCases := []struct {
+		inputXML    string
+		expectedErr error
+	}{
+		{ // NoncurrentDays only
+			inputXML:    `<NoncurrentVersionExpiration><NoncurrentDays>30</NoncurrentDays></NoncurrentVersionExpiration>`,
+			expectedErr: nil,
+		},
+		{ // NoncurrentDays with NewerNoncurrentVersions
+			inputXML:    `<NoncurrentVersionExpiration><NoncurrentDays>30</NoncurrentDays><NewerNoncurrentVersions>5</NewerNoncurrentVersions></NoncurrentVersionExpiration>`,
+			expectedErr: nil,
+		},
+		{ // NewerNoncurrentVersions only
+			inputXML:    `<NoncurrentVersionExpiration><NewerNoncurrentVersions>5</NewerNoncurrentVersions></NoncurrentVersionExpiration>`,
+			expectedErr: errNoncurrentNewerVersionsNoDays,
+		},
+		{ // Negative NewerNoncurrentVersions
+			inputXML:    `<NoncurrentVersionExpiration><NoncurrentDays>30</NoncurrentDays><NewerNoncurrentVersions>-1</NewerNoncurrentVersions></NoncurrentVersionExpiration>`,
+			expectedErr: errNoncurrentInvalidNewerVersions,
+		},
+		{ // Empty element
+			inputXML:    `<NoncurrentVersionExpiration></NoncurrentVersionExpiration>`,
+			expectedErr: errXMLNotWellFormed,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var n NoncurrentVersionExpiration
+			if err := xml.Unmarshal([]byte(tc.inputXML), &n); err != nil {
+				t.Fatal(err)
+			}
+			if err := n.Validate(); err != tc.expectedErr {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if tc.expectedErr != nil {
+				return
+			}
+			b, err := xml.Marshal(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.inputXML {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.inputXML, string(b))
+			}
+		})
+	}
+}
@@ -19,6 +19,12 @@ package lifecycle
 import (
 	"bytes"
 	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -40,16 +46,176 @@ type Rule struct {
 	Filter     Filter     `xml:"Filter,omitempty"`
 	Prefix     Prefix     `xml:"Prefix,omitempty"`
 	Expiration Expiration `xml:"Expiration,omitempty"`
-	Transition Transition `xml:"Transition,omitempty"`
-	// FIXME: add a type to catch unsupported AbortIncompleteMultipartUpload AbortIncompleteMultipartUpload `xml:"AbortIncompleteMultipartUpload,omitempty"`
-	NoncurrentVersionExpiration NoncurrentVersionExpiration `xml:"NoncurrentVersionExpiration,omitempty"`
-	NoncurrentVersionTransition NoncurrentVersionTransition `xml:"NoncurrentVersionTransition,omitempty"`
+	// Transitions holds one or more transition tiers, each moving
+	// the object to a colder storage class at a later time.
+	Transitions []Transition `xml:"Transition,omitempty"`
+
+	AbortIncompleteMultipartUpload AbortIncompleteMultipartUpload `xml:"AbortIncompleteMultipartUpload,omitempty"`
+	NoncurrentVersionExpiration    NoncurrentVersionExpiration    `xml:"NoncurrentVersionExpiration,omitempty"`
+	NoncurrentVersionTransition    NoncurrentVersionTransition    `xml:"NoncurrentVersionTransition,omitempty"`
+
+	// RawExtra holds the child elements unknown to this package, such
+	// as vendor extensions, so they are written back unchanged.
+	RawExtra []RawElement `xml:",any"`
+}
+
+// UnmarshalXML decodes a Rule, moving a Prefix given directly in Rule,
+// the legacy form, into an otherwise empty Filter.
+func (r *Rule) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type ruleWrapper Rule
+	var rule ruleWrapper
+	if err := d.DecodeElement(&rule, &start); err != nil {
+		return err
+	}
+	*r = Rule(rule)
+	// Transitions left unset, e.g. by "Indefinite" Days, take no action.
+	var transitions []Transition
+	for _, t := range r.Transitions {
+		if t.set {
+			transitions = append(transitions, t)
+		}
+	}
+	r.Transitions = transitions
+	r.normalizeLegacyPrefix()
+	return nil
+}
+
+// MarshalXML encodes a Rule, always in the Filter form.
+func (r Rule) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type ruleWrapper Rule
+	r.normalizeLegacyPrefix()
+	return e.EncodeElement(ruleWrapper(r), start)
+}
+
+// normalizeLegacyPrefix moves a Prefix of the rule into its Filter, unless
+// the Filter is specified too, even empty, an invalid rule left as is for
+// Validate.
+func (r *Rule) normalizeLegacyPrefix() {
+	if r.Prefix.set && !r.Filter.set && r.Filter.IsEmpty() {
+		r.Filter.Prefix = r.Prefix
+		r.Prefix = Prefix{}
+	}
+}
+
+// s3Namespace is the XML namespace of S3 API documents.
+const s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// RawElement is an XML element kept verbatim, with its attributes
+// and its content.
+type RawElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   []byte     `xml:",innerxml"`
+}
+
+// rawName drops the S3 namespace, or the namespace inherited from the
+// enclosing element, from name, restored by the encoder if needed.
+func rawName(name xml.Name, inherited string) xml.Name {
+	if name.Space == s3Namespace || name.Space == inherited {
+		name.Space = ""
+	}
+	return name
+}
+
+// rawAttrs drops any default namespace declaration from attrs, the
+// encoder declares the namespace of each element itself.
+func rawAttrs(attrs []xml.Attr) []xml.Attr {
+	var kept []xml.Attr
+	for _, attr := range attrs {
+		if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			continue
+		}
+		kept = append(kept, attr)
+	}
+	return kept
+}
+
+// UnmarshalXML decodes an element, dropping the S3 namespace and any
+// default namespace declaration, both restored by the encoder if needed.
+// The content is read token by token, as innerxml is only filled by a
+// decoder reading bytes, not by one reading tokens such as the decoder
+// of ParseLifecycleConfig.
+func (e *RawElement) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var inner bytes.Buffer
+	enc := xml.NewEncoder(&inner)
+	for depth := 0; ; {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			t.Name, t.Attr = rawName(t.Name, start.Name.Space), rawAttrs(t.Attr)
+			tok = t
+		case xml.EndElement:
+			if depth == 0 {
+				if err = enc.Flush(); err != nil {
+					return err
+				}
+				*e = RawElement{
+					XMLName: rawName(start.Name, ""),
+					Attrs:   rawAttrs(start.Attr),
+					Inner:   inner.Bytes(),
+				}
+				return nil
+			}
+			depth--
+			t.Name = rawName(t.Name, start.Name.Space)
+			tok = t
+		case xml.ProcInst:
+			if t.Target == "xml" {
+				// Only allowed at the start of a document.
+				continue
+			}
+		}
+		if err = enc.EncodeToken(tok); err != nil {
+			return err
+		}
+	}
+}
+
+// MarshalXML encodes an element with its attributes and content.
+func (e RawElement) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: e.XMLName, Attr: e.Attrs}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	d := xml.NewDecoder(bytes.NewReader(e.Inner))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if t, ok := tok.(xml.StartElement); ok {
+			t.Attr = rawAttrs(t.Attr)
+			tok = t
+		}
+		if err = enc.EncodeToken(tok); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
 }
 
 var (
 	errInvalidRuleID     = Errorf("ID length is limited to 255 characters")
 	errEmptyRuleStatus   = Errorf("Status should not be empty")
 	errInvalidRuleStatus = Errorf("Status must be set to either Enabled or Disabled")
+
+	errAbortIncompleteMultipartUploadWithTags = Errorf("AbortIncompleteMultipartUpload cannot be specified with tags in Filter")
+	errExpiredObjectDeleteMarkerWithTags      = Errorf("ExpiredObjectDeleteMarker cannot be specified with tags in Filter")
+
+	errTransitionDuplicateStorageClass = Errorf("StorageClass must be unique across Transitions of a rule")
+	errTransitionNotMonotonic          = Errorf("Transitions of a rule must be listed with strictly increasing Days or Date")
+	errTransitionNotColder             = Errorf("Transitions of a rule must move objects to colder storage classes over time")
+	errTransitionNotBeforeExpiration   = Errorf("Transition is not due before Expiration, objects expire before they are transitioned")
+
+	errRuleNoAction         = Errorf("At least one action needs to be specified in a rule")
+	errRulePrefixWithFilter = Errorf("Prefix cannot be specified in Rule along with Filter")
 )
 
 // generates random UUID
@@ -74,7 +240,7 @@ func (r Rule) validateID() error {
 			return err
 		}
 	} else if IDLen > 255 {
-		return errInvalidRuleID
+		return Errorf("%w, got %d bytes", errInvalidRuleID, IDLen)
 	}
 	return nil
 }
@@ -94,7 +260,15 @@ func (r Rule) validateStatus() error {
 }
 
 func (r Rule) validateExpiration() error {
-	return r.Expiration.Validate()
+	if err := r.Expiration.Validate(); err != nil {
+		return err
+	}
+	// Delete markers carry no tags, so a rule filtering
+	// by tags can never expire them.
+	if r.Expiration.DeleteMarker.set && r.Tags() != "" {
+		return errExpiredObjectDeleteMarkerWithTags
+	}
+	return nil
 }
 
 func (r Rule) validateNoncurrentExpiration() error {
@@ -102,7 +276,10 @@ func (r Rule) validateNoncurrentExpiration() error {
 }
 
 func (r Rule) validatePrefixAndFilter() error {
-	if !r.Prefix.set && r.Filter.IsEmpty() || r.Prefix.set && !r.Filter.IsEmpty() {
+	if r.Prefix.set && (r.Filter.set || !r.Filter.IsEmpty()) {
+		return errRulePrefixWithFilter
+	}
+	if !r.Prefix.set && r.Filter.IsEmpty() && !r.Filter.IsMatchAll() {
 		return errXMLNotWellFormed
 	}
 	if !r.Prefix.set {
@@ -112,13 +289,260 @@ func (r Rule) validatePrefixAndFilter() error {
 }
 
 func (r Rule) validateTransition() error {
-	return r.Transition.Validate()
+	storageClasses := make(map[string]struct{}, len(r.Transitions))
+	prevRank := -1
+	for i, t := range r.Transitions {
+		element := "Transition[" + strconv.Itoa(i) + "]"
+		if err := t.Validate(); err != nil {
+			return withElement(element, err)
+		}
+		if _, ok := storageClasses[t.StorageClass]; ok {
+			return withElement(element+".StorageClass", errTransitionDuplicateStorageClass)
+		}
+		storageClasses[t.StorageClass] = struct{}{}
+
+		// Ranked storage classes must get colder from one tier
+		// to the next, see SetStorageClassColdness.
+		if rank, ok := storageClassRank(t.StorageClass); ok {
+			if rank < prevRank {
+				return withElement(element+".StorageClass", errTransitionNotColder)
+			}
+			prevRank = rank
+		}
+
+		// Every tier must become due strictly after the previous one,
+		// which can only be compared if both use Days or both use Date.
+		if i == 0 {
+			continue
+		}
+		prev := r.Transitions[i-1]
+		switch {
+		case !prev.IsDateNull() && !t.IsDateNull():
+			if !t.Date.After(prev.Date.Time) {
+				return withElement(element+".Date", errTransitionNotMonotonic)
+			}
+		case prev.IsDateNull() && t.IsDateNull():
+			if t.Days <= prev.Days {
+				return withElement(element+".Days", errTransitionNotMonotonic)
+			}
+		default:
+			return withElement(element, errTransitionNotMonotonic)
+		}
+	}
+	return nil
+}
+
+// Normalize sorts the transition tiers of the rule, Days based tiers by
+// Days followed by Date based tiers by Date, and drops the redundant
+// ones: a tier to a storage class an earlier tier already moved objects
+// to, or to a ranked storage class no colder than an earlier tier's, see
+// SetStorageClassColdness. Validate should be called afterwards to catch
+// any remaining conflict.
+func (r *Rule) Normalize() {
+	if len(r.Transitions) == 0 {
+		return
+	}
+	// Copies of the rule may share the slice, leave theirs untouched.
+	r.Transitions = append([]Transition(nil), r.Transitions...)
+	sortTransitions(r.Transitions)
+	storageClasses := make(map[string]struct{}, len(r.Transitions))
+	prevRank := -1
+	tiers := r.Transitions[:0]
+	for _, t := range r.Transitions {
+		if _, ok := storageClasses[t.StorageClass]; ok {
+			continue
+		}
+		if rank, ok := storageClassRank(t.StorageClass); ok {
+			if rank <= prevRank {
+				continue
+			}
+			prevRank = rank
+		}
+		storageClasses[t.StorageClass] = struct{}{}
+		tiers = append(tiers, t)
+	}
+	r.Transitions = tiers
+}
+
+// sortTransitions sorts Days based tiers by Days followed by
+// Date based tiers by Date.
+func sortTransitions(transitions []Transition) {
+	sort.SliceStable(transitions, func(i, j int) bool {
+		a, b := transitions[i], transitions[j]
+		if a.IsDateNull() != b.IsDateNull() {
+			return a.IsDateNull()
+		}
+		if a.IsDateNull() {
+			return a.Days < b.Days
+		}
+		return a.Date.Before(b.Date.Time)
+	})
+}
+
+// mergeActions returns a copy of r with the actions of other added,
+// or false if both set the same action differently or their transition
+// tiers can't be combined.
+func (r Rule) mergeActions(other Rule) (Rule, bool) {
+	merged := r.Clone()
+	if other.Expiration.set {
+		if merged.Expiration.set && !xmlEqual(merged.Expiration, other.Expiration) {
+			return r, false
+		}
+		merged.Expiration = other.Expiration
+	}
+	if other.NoncurrentVersionExpiration.set {
+		if merged.NoncurrentVersionExpiration.set && !xmlEqual(merged.NoncurrentVersionExpiration, other.NoncurrentVersionExpiration) {
+			return r, false
+		}
+		merged.NoncurrentVersionExpiration = other.NoncurrentVersionExpiration
+	}
+	if other.NoncurrentVersionTransition.set {
+		if merged.NoncurrentVersionTransition.set && !xmlEqual(merged.NoncurrentVersionTransition, other.NoncurrentVersionTransition) {
+			return r, false
+		}
+		merged.NoncurrentVersionTransition = other.NoncurrentVersionTransition
+	}
+	if other.AbortIncompleteMultipartUpload.set {
+		if merged.AbortIncompleteMultipartUpload.set && !xmlEqual(merged.AbortIncompleteMultipartUpload, other.AbortIncompleteMultipartUpload) {
+			return r, false
+		}
+		merged.AbortIncompleteMultipartUpload = other.AbortIncompleteMultipartUpload
+	}
+	for _, t := range other.Transitions {
+		if !merged.hasTransition(t) {
+			merged.Transitions = append(merged.Transitions, t.Clone())
+		}
+	}
+	sortTransitions(merged.Transitions)
+	if merged.validateTransition() != nil {
+		return r, false
+	}
+	return merged, true
+}
+
+// hasTransition returns true if r has a tier identical to t.
+func (r Rule) hasTransition(t Transition) bool {
+	for _, rt := range r.Transitions {
+		if xmlEqual(rt, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// DueTransition returns the transition tier an object last modified at
+// modTime should be in at now, i.e. the due tier with the latest
+// threshold. It returns false if no tier is due yet.
+func (r Rule) DueTransition(modTime, now time.Time) (Transition, bool) {
+	var (
+		due     Transition
+		dueTime time.Time
+		found   bool
+	)
+	for _, t := range r.Transitions {
+		if ok, _ := t.ShouldTransition(modTime, now); !ok {
+			continue
+		}
+		if tt := t.transitionTime(modTime); !found || tt.After(dueTime) {
+			due, dueTime, found = t, tt, true
+		}
+	}
+	return due, found
+}
+
+// TransitionSchedule holds the transition tiers of a rule evaluated
+// at a fixed time, see Rule.TransitionSchedule.
+type TransitionSchedule struct {
+	tiers []scheduledTransition
+}
+
+type scheduledTransition struct {
+	Transition
+	// always is true for a Date based tier which is due at the
+	// evaluation time, regardless of the object modification time.
+	always bool
+	// cutoff is the midnight before which objects must have been
+	// last modified for a Days based tier to be due.
+	cutoff time.Time
+}
+
+// TransitionSchedule precomputes the transition tiers of the rule at now,
+// so that objects can be checked with Due without repeating the day
+// arithmetic of ExpectedExpiryTime per object. Due returns the same
+// result as DueTransition for the same now.
+func (r Rule) TransitionSchedule(now time.Time) TransitionSchedule {
+	s := TransitionSchedule{tiers: make([]scheduledTransition, 0, len(r.Transitions))}
+	// ExpectedExpiryTime(modTime, days) is a midnight, it is not after
+	// now iff it is not after the midnight preceding now, which holds
+	// iff modTime is before that midnight minus days.
+	today := now.UTC().Truncate(24 * time.Hour)
+	for _, t := range r.Transitions {
+		st := scheduledTransition{Transition: t}
+		switch {
+		case t.IsNull():
+		case !t.IsDateNull():
+			st.always = !now.Before(t.Date.Time)
+		default:
+			st.cutoff = today.Add(-time.Duration(t.days()) * 24 * time.Hour)
+		}
+		s.tiers = append(s.tiers, st)
+	}
+	return s
+}
+
+// Due returns the transition tier an object last modified at modTime
+// is due for, see Rule.DueTransition.
+func (s TransitionSchedule) Due(modTime time.Time) (Transition, bool) {
+	var (
+		due   Transition
+		found bool
+	)
+	for _, st := range s.tiers {
+		if !st.always && !modTime.Before(st.cutoff) {
+			continue
+		}
+		switch {
+		case !found:
+		case !due.IsDateNull() || !st.IsDateNull():
+			// Mixed or Date based tiers, compare the actual times.
+			if !st.transitionTime(modTime).After(due.transitionTime(modTime)) {
+				continue
+			}
+		case st.Days <= due.Days:
+			continue
+		}
+		due, found = st.Transition, true
+	}
+	return due, found
+}
+
+// isTransitionNull returns true if none of the transition tiers
+// has a date or a non-zero number of days.
+func (r Rule) isTransitionNull() bool {
+	for _, t := range r.Transitions {
+		if !t.IsNull() {
+			return false
+		}
+	}
+	return true
 }
 
 func (r Rule) validateNoncurrentTransition() error {
 	return r.NoncurrentVersionTransition.Validate()
 }
 
+func (r Rule) validateAbortIncompleteMultipartUpload() error {
+	if err := r.AbortIncompleteMultipartUpload.Validate(); err != nil {
+		return err
+	}
+	// Multipart uploads carry no tags, so a rule filtering
+	// by tags can never abort them.
+	if r.AbortIncompleteMultipartUpload.set && r.Tags() != "" {
+		return errAbortIncompleteMultipartUploadWithTags
+	}
+	return nil
+}
+
 // GetPrefix - a rule can either have prefix under <rule></rule>, <filter></filter>
 // or under <filter><and></and></filter>. This method returns the prefix from the
 // location where it is available.
@@ -155,31 +579,135 @@ func (r Rule) Tags() string {
 	return ""
 }
 
-// Validate - validates the rule element
+// Validate - validates the rule element, errors are annotated
+// with the offending element, see ValidationError.
 func (r Rule) Validate() error {
-	if err := r.validateID(); err != nil {
-		return err
+	for _, err := range r.ValidateAll() {
+		if !IsWarning(err) {
+			return err
+		}
 	}
-	if err := r.validateStatus(); err != nil {
-		return err
+	return nil
+}
+
+// ValidateAll is like Validate but reports the error of every invalid
+// element of the rule, in the order Validate checks them. It also
+// reports warnings which do not fail Validate, see IsWarning.
+func (r Rule) ValidateAll() []error {
+	var errs []error
+	add := func(element string, err error) {
+		if err == nil {
+			return
+		}
+		if element != "" {
+			err = withElement(element, err)
+		}
+		errs = append(errs, err)
 	}
-	if err := r.validateExpiration(); err != nil {
-		return err
+	add("ID", r.validateID())
+	add("Status", r.validateStatus())
+	add("Expiration", r.validateExpiration())
+	add("NoncurrentVersionExpiration", r.validateNoncurrentExpiration())
+	add("Filter", r.validatePrefixAndFilter())
+	add("", r.validateTransition())
+	add("", r.checkTransitionBeforeExpiration())
+	add("NoncurrentVersionTransition", r.validateNoncurrentTransition())
+	add("AbortIncompleteMultipartUpload", r.validateAbortIncompleteMultipartUpload())
+	if !r.hasAction() {
+		add("", errRuleNoAction)
 	}
-	if err := r.validateNoncurrentExpiration(); err != nil {
-		return err
+	return errs
+}
+
+// checkTransitionBeforeExpiration warns about transitions which are due
+// on or after the day objects expire and so never take effect. Days and
+// Date can only be compared if both use the same one.
+func (r Rule) checkTransitionBeforeExpiration() error {
+	if !r.Expiration.set {
+		return nil
 	}
-	if err := r.validatePrefixAndFilter(); err != nil {
-		return err
+	for i, t := range r.Transitions {
+		element := "Transition[" + strconv.Itoa(i) + "]"
+		switch {
+		case !t.IsDaysNull() && !r.Expiration.IsDaysNull():
+			if int(t.Days) >= int(r.Expiration.Days) {
+				return withElement(element+".Days", asWarning(errTransitionNotBeforeExpiration))
+			}
+		case !t.IsDateNull() && !r.Expiration.IsDateNull():
+			if !t.Date.Before(r.Expiration.Date.Time) {
+				return withElement(element+".Date", asWarning(errTransitionNotBeforeExpiration))
+			}
+		}
 	}
-	if err := r.validateTransition(); err != nil {
-		return err
+	return nil
+}
+
+// hasAction returns true if the rule specifies any action, a rule with
+// a Filter and a Status alone would never apply and AWS S3 rejects it.
+func (r Rule) hasAction() bool {
+	return r.Expiration.set || len(r.Transitions) > 0 ||
+		r.NoncurrentVersionExpiration.set || r.NoncurrentVersionTransition.set ||
+		r.AbortIncompleteMultipartUpload.set
+}
+
+// Clone returns a deep copy of the rule, which can be modified
+// without affecting the original.
+func (r Rule) Clone() Rule {
+	clone := r
+	if r.Transitions != nil {
+		clone.Transitions = make([]Transition, len(r.Transitions))
+		for i, t := range r.Transitions {
+			clone.Transitions[i] = t.Clone()
+		}
 	}
-	if err := r.validateNoncurrentTransition(); err != nil {
-		return err
+	if r.Filter.And.Tags != nil {
+		clone.Filter.And.Tags = make([]Tag, len(r.Filter.And.Tags))
+		copy(clone.Filter.And.Tags, r.Filter.And.Tags)
 	}
-	if !r.Expiration.set && !r.Transition.set && !r.NoncurrentVersionExpiration.set && !r.NoncurrentVersionTransition.set {
-		return errXMLNotWellFormed
+	if r.RawExtra != nil {
+		clone.RawExtra = make([]RawElement, len(r.RawExtra))
+		for i, e := range r.RawExtra {
+			clone.RawExtra[i] = RawElement{
+				XMLName: e.XMLName,
+				Attrs:   append([]xml.Attr(nil), e.Attrs...),
+				Inner:   append([]byte(nil), e.Inner...),
+			}
+		}
 	}
-	return nil
+	clone.Filter.cachedTags = nil
+	return clone
+}
+
+// String returns a human readable form of the rule, listing its
+// filter and each of the actions which are set.
+func (r Rule) String() string {
+	parts := []string{"ID=" + r.ID, "Status=" + string(r.Status)}
+	if prefix := r.GetPrefix(); prefix != "" {
+		parts = append(parts, "Prefix="+prefix)
+	}
+	if tags := r.Tags(); tags != "" {
+		parts = append(parts, "Tags="+tags)
+	}
+	switch {
+	case !r.Expiration.IsDateNull():
+		parts = append(parts, "Expiration(Date="+r.Expiration.Date.Format("2006-01-02")+")")
+	case !r.Expiration.IsDaysNull():
+		parts = append(parts, fmt.Sprintf("Expiration(Days=%d)", r.Expiration.Days))
+	case r.Expiration.DeleteMarker.set:
+		parts = append(parts, fmt.Sprintf("Expiration(ExpiredObjectDeleteMarker=%t)", r.Expiration.DeleteMarker.val))
+	}
+	for _, t := range r.Transitions {
+		parts = append(parts, t.String())
+	}
+	if !r.NoncurrentVersionExpiration.IsDaysNull() {
+		parts = append(parts, fmt.Sprintf("NoncurrentVersionExpiration(NoncurrentDays=%d)", r.NoncurrentVersionExpiration.NoncurrentDays))
+	}
+	if !r.NoncurrentVersionTransition.IsDaysNull() {
+		parts = append(parts, fmt.Sprintf("NoncurrentVersionTransition(NoncurrentDays=%d, StorageClass=%s)",
+			r.NoncurrentVersionTransition.NoncurrentDays, r.NoncurrentVersionTransition.StorageClass))
+	}
+	if r.AbortIncompleteMultipartUpload.set {
+		parts = append(parts, fmt.Sprintf("AbortIncompleteMultipartUpload(DaysAfterInitiation=%d)", r.AbortIncompleteMultipartUpload.DaysAfterInitiation))
+	}
+	return "Rule(" + strings.Join(parts, ", ") + ")"
 }
@@ -17,9 +17,14 @@
 package lifecycle
 
 import (
+	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"math/rand"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestInvalidRules checks if Rule xml with invalid elements returns
@@ -60,6 +65,14 @@ func TestInvalidRules(t *testing.T) {
 	                    </Rule>`,
 			expectedErr: errInvalidRuleStatus,
 		},
+		{ // Rule without any action
+			inputXML: ` <Rule>
+			                  <ID>rule without action</ID>
+                              <Filter><Prefix>logs/</Prefix></Filter>
+                              <Status>Enabled</Status>
+	                    </Rule>`,
+			expectedErr: errRuleNoAction,
+		},
 	}
 
 	for i, tc := range invalidTestCases {
@@ -70,9 +83,737 @@ func TestInvalidRules(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			if err := rule.Validate(); err != tc.expectedErr {
+			if err := rule.Validate(); !errors.Is(err, tc.expectedErr) {
 				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
 			}
 		})
 	}
 }
+
+// TestRuleTransitionsRoundTrip checks if a Rule carrying both a Transition
+// and a NoncurrentVersionTransition survives an XML round trip
+func TestRuleTransitionsRoundTrip(t *testing.T) {
+	inputXML := `<Rule><ID>transition-rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Transition><Days>30</Days><StorageClass>WARM</StorageClass></Transition><NoncurrentVersionTransition><NoncurrentDays>7</NoncurrentDays><StorageClass>COLD</StorageClass></NoncurrentVersionTransition></Rule>`
+
+	var rule Rule
+	if err := xml.Unmarshal([]byte(inputXML), &rule); err != nil {
+		t.Fatal(err)
+	}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(rule.Transitions) != 1 || rule.Transitions[0].Days != 30 || rule.Transitions[0].StorageClass != "WARM" {
+		t.Fatalf("Unexpected Transitions %v", rule.Transitions)
+	}
+	if rule.NoncurrentVersionTransition.NoncurrentDays != 7 || rule.NoncurrentVersionTransition.StorageClass != "COLD" {
+		t.Fatalf("Unexpected NoncurrentVersionTransition %v", rule.NoncurrentVersionTransition)
+	}
+
+	b, err := xml.Marshal(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rule1 Rule
+	if err = xml.Unmarshal(b, &rule1); err != nil {
+		t.Fatal(err)
+	}
+	b1, err := xml.Marshal(rule1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != string(b1) {
+		t.Fatalf("Expected %s to be equal to %s", string(b), string(b1))
+	}
+	if !rule1.Transitions[0].Equals(rule.Transitions[0]) || rule1.NoncurrentVersionTransition != rule.NoncurrentVersionTransition {
+		t.Fatalf("Expected %v to be equal to %v", rule1, rule)
+	}
+}
+
+// TestValidRules checks if complete Rule xml elements are parsed and
+// validated without errors
+func TestValidRules(t *testing.T) {
+	testCases := []struct {
+		inputXML       string
+		expectedID     string
+		expectedStatus Status
+		expectedPrefix string
+	}{
+		{ // Rule with expiration and transition
+			inputXML: `<Rule>
+							<ID>archive-then-expire</ID>
+							<Status>Enabled</Status>
+							<Filter><Prefix>logs/</Prefix></Filter>
+							<Expiration><Days>365</Days></Expiration>
+							<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>
+						</Rule>`,
+			expectedID:     "archive-then-expire",
+			expectedStatus: Enabled,
+			expectedPrefix: "logs/",
+		},
+		{ // Disabled rule with an And filter
+			inputXML: `<Rule>
+							<ID>tagged</ID>
+							<Status>Disabled</Status>
+							<Filter><And><Prefix>docs/</Prefix><Tag><Key>key1</Key><Value>val1</Value></Tag></And></Filter>
+							<Transition><Date>2021-01-01T00:00:00Z</Date><StorageClass>GLACIER</StorageClass></Transition>
+						</Rule>`,
+			expectedID:     "tagged",
+			expectedStatus: Disabled,
+			expectedPrefix: "docs/",
+		},
+		{ // Rule with a transition as its only action
+			inputXML: `<Rule>
+							<ID>archive</ID>
+							<Status>Enabled</Status>
+							<Filter><Prefix>logs/</Prefix></Filter>
+							<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>
+						</Rule>`,
+			expectedID:     "archive",
+			expectedStatus: Enabled,
+			expectedPrefix: "logs/",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var rule Rule
+			if err := xml.Unmarshal([]byte(tc.inputXML), &rule); err != nil {
+				t.Fatal(err)
+			}
+			if err := rule.Validate(); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			if rule.ID != tc.expectedID || rule.Status != tc.expectedStatus || rule.GetPrefix() != tc.expectedPrefix {
+				t.Fatalf("%d: Unexpected rule %v", i+1, rule)
+			}
+		})
+	}
+}
+
+// TestAbortIncompleteMultipartUploadRules checks validation of rules with
+// AbortIncompleteMultipartUpload
+func TestAbortIncompleteMultipartUploadRules(t *testing.T) {
+	testCases := []struct {
+		inputXML    string
+		expectedErr error
+	}{
+		{ // Abort with a prefix filter
+			inputXML:    `<Rule><ID>abort</ID><Status>Enabled</Status><Filter><Prefix>uploads/</Prefix></Filter><AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule>`,
+			expectedErr: nil,
+		},
+		{ // Abort with days as an attribute
+			inputXML:    `<Rule><ID>abort</ID><Status>Enabled</Status><Filter><Prefix>uploads/</Prefix></Filter><AbortIncompleteMultipartUpload DaysAfterInitiation="7"></AbortIncompleteMultipartUpload></Rule>`,
+			expectedErr: nil,
+		},
+		{ // Child element takes precedence over the attribute
+			inputXML:    `<Rule><ID>abort</ID><Status>Enabled</Status><Filter><Prefix>uploads/</Prefix></Filter><AbortIncompleteMultipartUpload DaysAfterInitiation="3"><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule>`,
+			expectedErr: nil,
+		},
+		{ // Abort without days
+			inputXML:    `<Rule><ID>abort</ID><Status>Enabled</Status><Filter><Prefix>uploads/</Prefix></Filter><AbortIncompleteMultipartUpload></AbortIncompleteMultipartUpload></Rule>`,
+			expectedErr: errAbortIncompleteMultipartUploadInvalidDays,
+		},
+		{ // Abort with negative days
+			inputXML:    `<Rule><ID>abort</ID><Status>Enabled</Status><Filter><Prefix>uploads/</Prefix></Filter><AbortIncompleteMultipartUpload><DaysAfterInitiation>-1</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule>`,
+			expectedErr: errAbortIncompleteMultipartUploadInvalidDays,
+		},
+		{ // Abort with a tag filter
+			inputXML:    `<Rule><ID>abort</ID><Status>Enabled</Status><Filter><Tag><Key>key1</Key><Value>value1</Value></Tag></Filter><AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule>`,
+			expectedErr: errAbortIncompleteMultipartUploadWithTags,
+		},
+		{ // Abort with tags in And
+			inputXML:    `<Rule><ID>abort</ID><Status>Enabled</Status><Filter><And><Prefix>uploads/</Prefix><Tag><Key>key1</Key><Value>value1</Value></Tag></And></Filter><AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule>`,
+			expectedErr: errAbortIncompleteMultipartUploadWithTags,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var rule Rule
+			if err := xml.Unmarshal([]byte(tc.inputXML), &rule); err != nil {
+				t.Fatal(err)
+			}
+			if err := rule.Validate(); !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if tc.expectedErr != nil {
+				return
+			}
+			b, err := xml.Marshal(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(b), "<AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload>") {
+				t.Fatalf("%d: Expected AbortIncompleteMultipartUpload in %s", i+1, string(b))
+			}
+		})
+	}
+}
+
+func TestRuleClone(t *testing.T) {
+	inputXML := `<Rule><ID>rule</ID><Status>Enabled</Status><Filter><And><Prefix>docs/</Prefix><Tag><Key>key1</Key><Value>val1</Value></Tag></And></Filter><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>`
+	var orig Rule
+	if err := xml.Unmarshal([]byte(inputXML), &orig); err != nil {
+		t.Fatal(err)
+	}
+	clone := orig.Clone()
+	clone.ID = "clone"
+	clone.Filter.And.Tags[0].Value = "val2"
+	clone.Transitions[0].Days = 60
+
+	if err := clone.Validate(); err != nil {
+		t.Fatalf("Expected clone to be valid but got %v", err)
+	}
+	b, err := xml.Marshal(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != inputXML {
+		t.Fatalf("Expected original to be unchanged %s but got %s", inputXML, string(b))
+	}
+}
+
+// TestRuleTransitionTiers checks validation of rules with several
+// Transition elements
+func TestRuleTransitionTiers(t *testing.T) {
+	testCases := []struct {
+		transitions string
+		expectedErr error
+	}{
+		{ // Increasing days
+			transitions: `<Transition><Days>30</Days><StorageClass>STANDARD_IA</StorageClass></Transition><Transition><Days>90</Days><StorageClass>GLACIER</StorageClass></Transition><Transition><Days>365</Days><StorageClass>DEEP_ARCHIVE</StorageClass></Transition>`,
+			expectedErr: nil,
+		},
+		{ // Increasing dates
+			transitions: `<Transition><Date>2021-01-01T00:00:00Z</Date><StorageClass>STANDARD_IA</StorageClass></Transition><Transition><Date>2021-06-01T00:00:00Z</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: nil,
+		},
+		{ // Duplicate storage class
+			transitions: `<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition><Transition><Days>90</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionDuplicateStorageClass,
+		},
+		{ // Decreasing days
+			transitions: `<Transition><Days>90</Days><StorageClass>STANDARD_IA</StorageClass></Transition><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionNotMonotonic,
+		},
+		{ // Same days
+			transitions: `<Transition><Days>30</Days><StorageClass>STANDARD_IA</StorageClass></Transition><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionNotMonotonic,
+		},
+		{ // Days mixed with Date
+			transitions: `<Transition><Days>30</Days><StorageClass>STANDARD_IA</StorageClass></Transition><Transition><Date>2021-06-01T00:00:00Z</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionNotMonotonic,
+		},
+		{ // Colder storage class before a warmer one
+			transitions: `<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition><Transition><Days>90</Days><StorageClass>STANDARD_IA</StorageClass></Transition>`,
+			expectedErr: errTransitionNotColder,
+		},
+		{ // Unranked storage classes in between are not checked
+			transitions: `<Transition><Days>30</Days><StorageClass>DEEP_ARCHIVE</StorageClass></Transition><Transition><Days>90</Days><StorageClass>WARM</StorageClass></Transition><Transition><Days>120</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionNotColder,
+		},
+		{ // Unranked storage classes only
+			transitions: `<Transition><Days>30</Days><StorageClass>COLD</StorageClass></Transition><Transition><Days>90</Days><StorageClass>WARM</StorageClass></Transition>`,
+			expectedErr: nil,
+		},
+		{ // Glacier Instant Retrieval between STANDARD_IA and GLACIER
+			transitions: `<Transition><Days>30</Days><StorageClass>STANDARD_IA</StorageClass></Transition><Transition><Days>60</Days><StorageClass>GLACIER_IR</StorageClass></Transition><Transition><Days>90</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: nil,
+		},
+		{
+			transitions: `<Transition><Days>30</Days><StorageClass>GLACIER_IR</StorageClass></Transition>`,
+			expectedErr: nil,
+		},
+		{
+			transitions: `<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition><Transition><Days>90</Days><StorageClass>GLACIER_IR</StorageClass></Transition>`,
+			expectedErr: errTransitionNotColder,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var rule Rule
+			inputXML := `<Rule><ID>tiers</ID><Status>Enabled</Status><Filter><Prefix></Prefix></Filter>` + tc.transitions + `</Rule>`
+			if err := xml.Unmarshal([]byte(inputXML), &rule); err != nil {
+				t.Fatal(err)
+			}
+			if err := rule.Validate(); !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if tc.expectedErr != nil {
+				return
+			}
+			b, err := xml.Marshal(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(b), tc.transitions) {
+				t.Fatalf("%d: Expected %s in %s", i+1, tc.transitions, string(b))
+			}
+		})
+	}
+}
+
+func TestRuleDueTransition(t *testing.T) {
+	var rule Rule
+	inputXML := `<Rule><ID>tiers</ID><Status>Enabled</Status><Filter><Prefix></Prefix></Filter><Transition><Days>30</Days><StorageClass>STANDARD_IA</StorageClass></Transition><Transition><Days>90</Days><StorageClass>GLACIER</StorageClass></Transition><Transition><Days>365</Days><StorageClass>DEEP_ARCHIVE</StorageClass></Transition></Rule>`
+	if err := xml.Unmarshal([]byte(inputXML), &rule); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		age                  time.Duration
+		expectedDue          bool
+		expectedStorageClass string
+	}{
+		{age: 10 * 24 * time.Hour, expectedDue: false},
+		{age: 45 * 24 * time.Hour, expectedDue: true, expectedStorageClass: "STANDARD_IA"},
+		{age: 120 * 24 * time.Hour, expectedDue: true, expectedStorageClass: "GLACIER"},
+		{age: 400 * 24 * time.Hour, expectedDue: true, expectedStorageClass: "DEEP_ARCHIVE"},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			transition, due := rule.DueTransition(now.Add(-tc.age), now)
+			if due != tc.expectedDue || transition.StorageClass != tc.expectedStorageClass {
+				t.Fatalf("%d: Expected (%v, %s) but got (%v, %s)", i+1, tc.expectedDue, tc.expectedStorageClass, due, transition.StorageClass)
+			}
+		})
+	}
+}
+
+// TestRuleBuilder checks if rules built through NewRule are valid
+// and marshal to XML which parses back into a valid configuration
+func TestRuleBuilder(t *testing.T) {
+	midnight := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		builder     *RuleBuilder
+		expectedXML string
+		expectedErr error
+	}{
+		{
+			builder:     NewRule("expire-logs").WithPrefix("logs/").ExpireAfterDays(30),
+			expectedXML: "<Rule><ID>expire-logs</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>30</Days></Expiration></Rule>",
+		},
+		{
+			builder:     NewRule("tiered").WithTag("class", "archive").TransitionAfterDays(30, "WARM").TransitionAfterDays(90, "COLD"),
+			expectedXML: "<Rule><ID>tiered</ID><Status>Enabled</Status><Filter><Tag><Key>class</Key><Value>archive</Value></Tag></Filter><Transition><Days>30</Days><StorageClass>WARM</StorageClass></Transition><Transition><Days>90</Days><StorageClass>COLD</StorageClass></Transition></Rule>",
+		},
+		{
+			builder:     NewRule("and").Disabled().WithPrefix("data/").WithTag("k", "v").ExpireOnDate(midnight),
+			expectedXML: "<Rule><ID>and</ID><Status>Disabled</Status><Filter><And><Prefix>data/</Prefix><Tag><Key>k</Key><Value>v</Value></Tag></And></Filter><Expiration><Date>2024-06-01T00:00:00Z</Date></Expiration></Rule>",
+		},
+		{
+			builder:     NewRule("abort").AbortIncompleteMultipartUploadAfterDays(7),
+			expectedXML: "<Rule><ID>abort</ID><Status>Enabled</Status><Filter><Prefix></Prefix></Filter><AbortIncompleteMultipartUpload><DaysAfterInitiation>7</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule>",
+		},
+		{ // First error is reported
+			builder:     NewRule("bad").ExpireAfterDays(0).TransitionAfterDays(-1, "WARM"),
+			expectedErr: errLifecycleInvalidDays,
+		},
+		{
+			builder:     NewRule("bad").ExpireOnDate(midnight.Add(time.Minute)),
+			expectedErr: errLifecycleDateNotMidnight,
+		},
+		{ // Rule validation errors are returned from Build
+			builder:     NewRule("bad").TransitionAfterDays(90, "COLD").TransitionAfterDays(30, "WARM"),
+			expectedErr: errTransitionNotMonotonic,
+		},
+		{ // No action
+			builder:     NewRule("bad").WithPrefix("logs/"),
+			expectedErr: errRuleNoAction,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			rule, err := tc.builder.Build()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if tc.expectedErr != nil {
+				return
+			}
+			b, err := xml.Marshal(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.expectedXML {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedXML, string(b))
+			}
+			lc, err := ParseLifecycleConfig(strings.NewReader("<LifecycleConfiguration>" + string(b) + "</LifecycleConfiguration>"))
+			if err != nil {
+				t.Fatalf("%d: Expected built rule to parse but got %v", i+1, err)
+			}
+			if err = lc.Validate(); err != nil {
+				t.Fatalf("%d: Expected built rule to be valid but got %v", i+1, err)
+			}
+		})
+	}
+}
+
+func TestAbortIncompleteMultipartUploadAttr(t *testing.T) {
+	var abort AbortIncompleteMultipartUpload
+	if err := xml.Unmarshal([]byte(`<AbortIncompleteMultipartUpload DaysAfterInitiation=" 5 "/>`), &abort); err != nil {
+		t.Fatal(err)
+	}
+	if abort.DaysAfterInitiation != 5 {
+		t.Fatalf("Expected 5 but got %d", abort.DaysAfterInitiation)
+	}
+	if err := xml.Unmarshal([]byte(`<AbortIncompleteMultipartUpload DaysAfterInitiation="five"/>`), &abort); err != errAbortIncompleteMultipartUploadInvalidDays {
+		t.Fatalf("Expected %v but got %v", errAbortIncompleteMultipartUploadInvalidDays, err)
+	}
+}
+
+// TestTransitionScheduleDifferential checks if TransitionSchedule.Due
+// agrees with DueTransition for random objects, tiers and times
+func TestTransitionScheduleDifferential(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	base := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	randTime := func() time.Time {
+		return base.Add(time.Duration(rnd.Int63n(int64(400 * 24 * time.Hour))))
+	}
+	for i := 0; i < 1000; i++ {
+		var rule Rule
+		for j := rnd.Intn(4); j >= 0; j-- {
+			tr := Transition{StorageClass: fmt.Sprintf("TIER%d", j), set: true}
+			switch rnd.Intn(3) {
+			case 0:
+				tr.Date = TransitionDate{Time: randTime().Truncate(24 * time.Hour)}
+			case 1:
+				tr.Days = TransitionDays(rnd.Intn(200))
+			}
+			rule.Transitions = append(rule.Transitions, tr)
+		}
+		now := randTime()
+		// Exactly at midnight is the inclusive boundary
+		if i%10 == 0 {
+			now = now.Truncate(24 * time.Hour)
+		}
+		schedule := rule.TransitionSchedule(now)
+		for k := 0; k < 100; k++ {
+			modTime := randTime()
+			if k%10 == 0 {
+				modTime = modTime.Truncate(24 * time.Hour)
+			}
+			expected, expectedOk := rule.DueTransition(modTime, now)
+			got, gotOk := schedule.Due(modTime)
+			if gotOk != expectedOk || !got.Equals(expected) {
+				t.Fatalf("%d: Expected %v, %v but got %v, %v for %v at %v with %v",
+					i+1, expected, expectedOk, got, gotOk, modTime, now, rule.Transitions)
+			}
+		}
+	}
+}
+
+func benchmarkTransitionRule() Rule {
+	return Rule{Transitions: []Transition{
+		{Days: 30, StorageClass: "WARM", set: true},
+		{Days: 90, StorageClass: "COLD", set: true},
+		{Days: 365, StorageClass: "GLACIER", set: true},
+	}}
+}
+
+func BenchmarkDueTransition(b *testing.B) {
+	rule := benchmarkTransitionRule()
+	now := time.Now().UTC()
+	modTime := now.Add(-100 * 24 * time.Hour)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rule.DueTransition(modTime, now)
+	}
+}
+
+func BenchmarkTransitionScheduleDue(b *testing.B) {
+	rule := benchmarkTransitionRule()
+	now := time.Now().UTC()
+	modTime := now.Add(-100 * 24 * time.Hour)
+	schedule := rule.TransitionSchedule(now)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		schedule.Due(modTime)
+	}
+}
+
+// TestStorageClassColdnessOverride checks if a custom ranking is used
+// to validate the order of transitions
+func TestStorageClassColdnessOverride(t *testing.T) {
+	defer SetStorageClassColdness(nil)
+
+	inputXML := `<Rule><ID>tiers</ID><Status>Enabled</Status><Filter><Prefix></Prefix></Filter>` +
+		`<Transition><Days>30</Days><StorageClass>COLD</StorageClass></Transition><Transition><Days>90</Days><StorageClass>WARM</StorageClass></Transition></Rule>`
+	var rule Rule
+	if err := xml.Unmarshal([]byte(inputXML), &rule); err != nil {
+		t.Fatal(err)
+	}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("Expected no error with the default ranking but got %v", err)
+	}
+	SetStorageClassColdness([]string{"HOT", "WARM", "COLD"})
+	if err := rule.Validate(); !errors.Is(err, errTransitionNotColder) {
+		t.Fatalf("Expected %v but got %v", errTransitionNotColder, err)
+	}
+}
+
+// TestRuleRawExtra checks if elements unknown to this package
+// survive an XML round trip
+func TestRuleRawExtra(t *testing.T) {
+	testCases := []struct {
+		inputXML    string
+		expectedXML string
+	}{
+		{ // Vendor element with attributes and nested content
+			inputXML:    `<Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration><VendorPolicy mode="strict"><Tier>hot</Tier><Tier>cold</Tier></VendorPolicy></Rule>`,
+			expectedXML: `<Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration><VendorPolicy mode="strict"><Tier>hot</Tier><Tier>cold</Tier></VendorPolicy></Rule>`,
+		},
+		{ // Vendor elements in the S3 namespace, before known elements
+			inputXML:    `<Rule xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Replicate>true</Replicate><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration><Empty/></Rule>`,
+			expectedXML: `<Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration><Replicate>true</Replicate><Empty></Empty></Rule>`,
+		},
+		{ // Vendor element in its own namespace
+			inputXML:    `<Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration><Hint xmlns="urn:vendor">fast</Hint></Rule>`,
+			expectedXML: `<Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration><Hint xmlns="urn:vendor">fast</Hint></Rule>`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var rule Rule
+			if err := xml.Unmarshal([]byte(tc.inputXML), &rule); err != nil {
+				t.Fatal(err)
+			}
+			if err := rule.Validate(); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			if rule.ID != "rule" || rule.GetPrefix() != "logs/" || rule.Expiration.Days != 3 {
+				t.Fatalf("%d: Expected known elements to be parsed but got %v", i+1, rule)
+			}
+			b, err := xml.Marshal(rule.Clone())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.expectedXML {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedXML, string(b))
+			}
+		})
+	}
+}
+
+// TestRawElementParseRoundTrip checks if the content of unknown elements
+// is kept when parsed with ParseLifecycleConfig, which decodes tokens
+func TestRawElementParseRoundTrip(t *testing.T) {
+	testCases := []struct {
+		inputXML    string
+		expectedXML string
+	}{
+		{
+			inputXML:    `<LifecycleConfiguration><Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration><VendorExt foo="bar"><Inner>1</Inner></VendorExt></Rule></LifecycleConfiguration>`,
+			expectedXML: `<LifecycleConfiguration><Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration><VendorExt foo="bar"><Inner>1</Inner></VendorExt></Rule></LifecycleConfiguration>`,
+		},
+		{ // In the S3 namespace, with escaped text and nested elements
+			inputXML:    `<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration><VendorExt><Inner a="1">x &amp; y<Leaf/></Inner></VendorExt></Rule></LifecycleConfiguration>`,
+			expectedXML: `<LifecycleConfiguration><Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration><VendorExt><Inner a="1">x &amp; y<Leaf></Leaf></Inner></VendorExt></Rule></LifecycleConfiguration>`,
+		},
+		{ // In its own namespace
+			inputXML:    `<LifecycleConfiguration><Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration><Hint xmlns="urn:vendor"><Speed>fast</Speed></Hint></Rule></LifecycleConfiguration>`,
+			expectedXML: `<LifecycleConfiguration><Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration><Hint xmlns="urn:vendor"><Speed>fast</Speed></Hint></Rule></LifecycleConfiguration>`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			lc, err := ParseLifecycleConfig(strings.NewReader(tc.inputXML))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if _, err = lc.WriteTo(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tc.expectedXML {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedXML, buf.String())
+			}
+			// Written content parses back to the same configuration
+			reparsed, err := ParseLifecycleConfig(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changes := Diff(*lc, *reparsed); len(changes) != 0 {
+				t.Fatalf("%d: Expected no changes but got %v", i+1, changes)
+			}
+		})
+	}
+}
+
+// TestRuleLegacyPrefix checks if a Prefix given directly in Rule is
+// moved into Filter, and rejected along with a Filter
+func TestRuleLegacyPrefix(t *testing.T) {
+	testCases := []struct {
+		inputXML       string
+		expectedPrefix string
+		expectedXML    string
+		expectedErr    error
+	}{
+		{ // Legacy form
+			inputXML:       `<Rule><ID>legacy</ID><Prefix>logs/</Prefix><Status>Enabled</Status><Expiration><Days>3</Days></Expiration></Rule>`,
+			expectedPrefix: "logs/",
+			expectedXML:    `<Rule><ID>legacy</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule>`,
+		},
+		{ // Legacy form with an empty prefix
+			inputXML:       `<Rule><ID>legacy</ID><Prefix></Prefix><Status>Enabled</Status><Expiration><Days>3</Days></Expiration></Rule>`,
+			expectedPrefix: "",
+			expectedXML:    `<Rule><ID>legacy</ID><Status>Enabled</Status><Filter><Prefix></Prefix></Filter><Expiration><Days>3</Days></Expiration></Rule>`,
+		},
+		{ // Legacy form along with an empty Filter element
+			inputXML:    `<Rule><ID>legacy</ID><Filter></Filter><Prefix>logs/</Prefix><Status>Enabled</Status><Expiration><Days>3</Days></Expiration></Rule>`,
+			expectedErr: errRulePrefixWithFilter,
+		},
+		{
+			inputXML:    `<Rule><ID>legacy</ID><Prefix></Prefix><Filter/><Status>Enabled</Status><Expiration><Days>3</Days></Expiration></Rule>`,
+			expectedErr: errRulePrefixWithFilter,
+		},
+		{ // Both legacy Prefix and Filter
+			inputXML:    `<Rule><ID>both</ID><Prefix>logs/</Prefix><Filter><Prefix>tmp/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>3</Days></Expiration></Rule>`,
+			expectedErr: errRulePrefixWithFilter,
+		},
+		{
+			inputXML:    `<Rule><ID>both</ID><Prefix>logs/</Prefix><Filter><Tag><Key>k</Key><Value>v</Value></Tag></Filter><Status>Enabled</Status><Expiration><Days>3</Days></Expiration></Rule>`,
+			expectedErr: errRulePrefixWithFilter,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var rule Rule
+			if err := xml.Unmarshal([]byte(tc.inputXML), &rule); err != nil {
+				t.Fatal(err)
+			}
+			err := rule.Validate()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if tc.expectedErr != nil {
+				return
+			}
+			if rule.Prefix.set || rule.Filter.Prefix.String() != tc.expectedPrefix || rule.GetPrefix() != tc.expectedPrefix {
+				t.Fatalf("%d: Expected Filter with prefix %q but got %v", i+1, tc.expectedPrefix, rule)
+			}
+			b, err := xml.Marshal(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.expectedXML {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedXML, string(b))
+			}
+		})
+	}
+
+	// Rules built with the legacy Prefix are marshaled in the Filter form
+	rule := Rule{ID: "legacy", Status: Enabled, Prefix: Prefix{string: "logs/", set: true}, Expiration: Expiration{Days: ExpirationDays(3), set: true}}
+	b, err := xml.Marshal(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := testCases[0].expectedXML; string(b) != expected {
+		t.Fatalf("Expected %s but got %s", expected, string(b))
+	}
+}
+
+func TestExpiredObjectDeleteMarkerWithTags(t *testing.T) {
+	testCases := []struct {
+		inputXML    string
+		expectedErr error
+	}{
+		{ // Delete marker expiration with a prefix filter
+			inputXML:    `<Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><ExpiredObjectDeleteMarker>true</ExpiredObjectDeleteMarker></Expiration></Rule>`,
+			expectedErr: nil,
+		},
+		{ // Delete marker expiration with a tag filter
+			inputXML:    `<Rule><ID>rule</ID><Status>Enabled</Status><Filter><Tag><Key>key1</Key><Value>value1</Value></Tag></Filter><Expiration><ExpiredObjectDeleteMarker>true</ExpiredObjectDeleteMarker></Expiration></Rule>`,
+			expectedErr: errExpiredObjectDeleteMarkerWithTags,
+		},
+		{ // Delete marker expiration with tags in And
+			inputXML:    `<Rule><ID>rule</ID><Status>Enabled</Status><Filter><And><Prefix>logs/</Prefix><Tag><Key>key1</Key><Value>value1</Value></Tag></And></Filter><Expiration><ExpiredObjectDeleteMarker>false</ExpiredObjectDeleteMarker></Expiration></Rule>`,
+			expectedErr: errExpiredObjectDeleteMarkerWithTags,
+		},
+		{ // Days expiration with a tag filter
+			inputXML:    `<Rule><ID>rule</ID><Status>Enabled</Status><Filter><Tag><Key>key1</Key><Value>value1</Value></Tag></Filter><Expiration><Days>3</Days></Expiration></Rule>`,
+			expectedErr: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var rule Rule
+			if err := xml.Unmarshal([]byte(tc.inputXML), &rule); err != nil {
+				t.Fatal(err)
+			}
+			err := rule.Validate()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			var ve ValidationError
+			if tc.expectedErr != nil && (!errors.As(err, &ve) || ve.Path() != "Expiration") {
+				t.Fatalf("%d: Expected the error on Expiration but got %v", i+1, err)
+			}
+		})
+	}
+}
+
+func TestRuleNormalize(t *testing.T) {
+	testCases := []struct {
+		transitions string
+		expected    string
+	}{
+		{ // Redundant tier to the same storage class
+			transitions: `<Transition><Days>30</Days><StorageClass>STANDARD_IA</StorageClass></Transition>` +
+				`<Transition><Days>45</Days><StorageClass>STANDARD_IA</StorageClass></Transition>`,
+			expected: "Transition(Days=30, StorageClass=STANDARD_IA)",
+		},
+		{ // Unsorted tiers
+			transitions: `<Transition><Days>90</Days><StorageClass>GLACIER</StorageClass></Transition>` +
+				`<Transition><Days>30</Days><StorageClass>STANDARD_IA</StorageClass></Transition>`,
+			expected: "Transition(Days=30, StorageClass=STANDARD_IA) Transition(Days=90, StorageClass=GLACIER)",
+		},
+		{ // Tier to a warmer storage class than an earlier one
+			transitions: `<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>` +
+				`<Transition><Days>60</Days><StorageClass>STANDARD_IA</StorageClass></Transition>` +
+				`<Transition><Days>180</Days><StorageClass>DEEP_ARCHIVE</StorageClass></Transition>`,
+			expected: "Transition(Days=30, StorageClass=GLACIER) Transition(Days=180, StorageClass=DEEP_ARCHIVE)",
+		},
+		{ // Unranked storage classes are kept
+			transitions: `<Transition><Days>60</Days><StorageClass>COLDTIER</StorageClass></Transition>` +
+				`<Transition><Days>30</Days><StorageClass>WARMTIER</StorageClass></Transition>`,
+			expected: "Transition(Days=30, StorageClass=WARMTIER) Transition(Days=60, StorageClass=COLDTIER)",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var rule Rule
+			input := `<Rule><ID>rule</ID><Status>Enabled</Status><Filter><Prefix></Prefix></Filter>` + tc.transitions + `</Rule>`
+			if err := xml.Unmarshal([]byte(input), &rule); err != nil {
+				t.Fatal(err)
+			}
+			orig := rule.Clone()
+			copied := rule
+			rule.Normalize()
+			var got []string
+			for _, tr := range rule.Transitions {
+				got = append(got, tr.String())
+			}
+			if strings.Join(got, " ") != tc.expected {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expected, strings.Join(got, " "))
+			}
+			if err := rule.Validate(); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			// Copies of the rule are not affected
+			for j := range orig.Transitions {
+				if !copied.Transitions[j].Equals(orig.Transitions[j]) {
+					t.Fatalf("%d: Expected %v but got %v", i+1, orig.Transitions, copied.Transitions)
+				}
+			}
+		})
+	}
+}
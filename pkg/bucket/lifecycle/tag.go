@@ -19,6 +19,8 @@ package lifecycle
 import (
 	"encoding/xml"
 	"io"
+	"strings"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -87,13 +89,27 @@ func (tag Tag) IsEmpty() bool {
 	return tag.Key == ""
 }
 
+// isValidTagString returns true if s only contains characters allowed
+// in tags, i.e. letters, numbers, spaces and + - = . _ : / @
+func isValidTagString(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsSpace(r) {
+			continue
+		}
+		if !strings.ContainsRune("+-=._:/@", r) {
+			return false
+		}
+	}
+	return true
+}
+
 // Validate checks this tag.
 func (tag Tag) Validate() error {
-	if len(tag.Key) == 0 || utf8.RuneCountInString(tag.Key) > 128 {
+	if len(tag.Key) == 0 || utf8.RuneCountInString(tag.Key) > 128 || !isValidTagString(tag.Key) {
 		return errInvalidTagKey
 	}
 
-	if utf8.RuneCountInString(tag.Value) > 256 {
+	if utf8.RuneCountInString(tag.Value) > 256 || !isValidTagString(tag.Value) {
 		return errInvalidTagValue
 	}
 
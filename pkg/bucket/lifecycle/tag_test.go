@@ -0,0 +1,87 @@
+/*
+ * MinIO Cloud Storage, (C) 2021 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lifecycle
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTagValidate(t *testing.T) {
+	testCases := []struct {
+		tag         Tag
+		expectedErr error
+	}{
+		{
+			tag:         Tag{Key: "project", Value: "lifecycle-2021"},
+			expectedErr: nil,
+		},
+		{ // Allowed punctuation, spaces and non ASCII letters
+			tag:         Tag{Key: "team/owner:name", Value: "jane.doe+ops@example.com = ü"},
+			expectedErr: nil,
+		},
+		{ // Empty value is allowed
+			tag:         Tag{Key: "key"},
+			expectedErr: nil,
+		},
+		{
+			tag:         Tag{Key: strings.Repeat("k", 129), Value: "value"},
+			expectedErr: errInvalidTagKey,
+		},
+		{ // 128 multi byte characters is within limits
+			tag:         Tag{Key: strings.Repeat("ü", 128), Value: "value"},
+			expectedErr: nil,
+		},
+		{
+			tag:         Tag{Value: "value"},
+			expectedErr: errInvalidTagKey,
+		},
+		{
+			tag:         Tag{Key: "key", Value: strings.Repeat("v", 257)},
+			expectedErr: errInvalidTagValue,
+		},
+		{
+			tag:         Tag{Key: "key*", Value: "value"},
+			expectedErr: errInvalidTagKey,
+		},
+		{
+			tag:         Tag{Key: "key", Value: "value#1"},
+			expectedErr: errInvalidTagValue,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if err := tc.tag.Validate(); err != tc.expectedErr {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestAndDuplicateTags(t *testing.T) {
+	var f Filter
+	inputXML := `<Filter><And><Prefix>p</Prefix><Tag><Key>key</Key><Value>a</Value></Tag><Tag><Key>key</Key><Value>b</Value></Tag></And></Filter>`
+	if err := xml.Unmarshal([]byte(inputXML), &f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Validate(); err != errDuplicateTagKey {
+		t.Fatalf("Expected %v but got %v", errDuplicateTagKey, err)
+	}
+}
@@ -17,7 +17,11 @@
 package lifecycle
 
 import (
+	"encoding/json"
 	"encoding/xml"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,12 +30,183 @@ var (
 	errTransitionInvalidDate     = Errorf("Date must be provided in ISO 8601 format")
 	errTransitionInvalid         = Errorf("Exactly one of Days (0 or greater) or Date (positive ISO 8601 format) should be present inside Expiration.")
 	errTransitionDateNotMidnight = Errorf("'Date' must be at midnight GMT")
+	errTransitionDaysTooLarge    = Errorf("Days must not exceed 36500 when used with Transition")
+
+	errTransitionInvalidStorageClass = Errorf("StorageClass is not one of the allowed storage classes")
+	errTransitionDateInPast          = Errorf("'Date' must not be earlier than the reference date")
 )
 
+// defaultStorageClassColdness ranks the AWS S3 storage classes,
+// see SetStorageClassColdness.
+var defaultStorageClassColdness = []string{
+	"STANDARD",
+	"STANDARD_IA",
+	"INTELLIGENT_TIERING",
+	"ONEZONE_IA",
+	"GLACIER_IR",
+	"GLACIER",
+	"DEEP_ARCHIVE",
+}
+
+// storageClassColdness holds the ranking set by SetStorageClassColdness.
+var storageClassColdness atomic.Value
+
+// SetStorageClassColdness sets the ranking of the storage classes known
+// to be colder than one another, from the warmest to the coldest, e.g.
+// to rank the tiers of other backends. Transitions of a rule to ranked
+// storage classes must move objects to colder classes over time, storage
+// classes missing from the ranking are not checked. Passing nil restores
+// the default ranking of the AWS S3 storage classes.
+func SetStorageClassColdness(ranking []string) {
+	storageClassColdness.Store(append([]string(nil), ranking...))
+}
+
+// StorageClassColdness returns a copy of the ranking of storage classes,
+// from the warmest to the coldest, see SetStorageClassColdness.
+func StorageClassColdness() []string {
+	return append([]string(nil), storageClassRanking()...)
+}
+
+// storageClassRanking returns the ranking in use, which must not be
+// modified.
+func storageClassRanking() []string {
+	if ranking, ok := storageClassColdness.Load().([]string); ok && ranking != nil {
+		return ranking
+	}
+	return defaultStorageClassColdness
+}
+
+// storageClassRank returns the position of storageClass in the
+// ranking of StorageClassColdness, or false if it is not ranked.
+func storageClassRank(storageClass string) (int, bool) {
+	for i, sc := range storageClassRanking() {
+		if sc == storageClass {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// CompareStorageClasses orders storage classes by coldness, as ranked by
+// StorageClassColdness, returning -1 if a is warmer than b, 0 if they
+// are the same and +1 if a is colder than b. Storage classes missing
+// from the ranking come after the ranked ones, ordered by name, so
+// CompareStorageClasses(a, b) < 0 can be used as a sort.Slice less func.
+func CompareStorageClasses(a, b string) int {
+	aRank, aOk := storageClassRank(a)
+	bRank, bOk := storageClassRank(b)
+	switch {
+	case aOk && bOk:
+		switch {
+		case aRank < bRank:
+			return -1
+		case aRank > bRank:
+			return 1
+		}
+		return 0
+	case aOk:
+		return -1
+	case bOk:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
 // TransitionDate is a embedded type containing time.Time to unmarshal
 // Date in Transition
 type TransitionDate struct {
 	time.Time
+	// layout is the layout Date was given in when it is not
+	// RFC 3339, kept to write Date back the same way.
+	layout string
+}
+
+// transitionDateOnlyLayout is the ISO 8601 layout of a date
+// without a time of day.
+const transitionDateOnlyLayout = "2006-01-02"
+
+// minTransitionEpochDigits is the number of digits a Date given as
+// seconds since the Unix epoch must have at least, i.e. a date after
+// September 2001, so that e.g. "20240101" isn't taken for one.
+const minTransitionEpochDigits = 10
+
+// parseTransitionDate parses and validates a Date in Transition
+func parseTransitionDate(dateStr string) (TransitionDate, error) {
+	// Hand edited configurations may carry whitespace around the date,
+	// comments are already left out by the XML decoder.
+	dateStr = strings.TrimSpace(dateStr)
+	var (
+		trnDate time.Time
+		layout  string
+	)
+	if len(dateStr) >= minTransitionEpochDigits && strings.TrimLeft(dateStr, "0123456789") == "" {
+		// Some legacy configurations carry the date as
+		// seconds since the Unix epoch.
+		secs, err := strconv.ParseInt(dateStr, 10, 64)
+		if err != nil {
+			return TransitionDate{}, withCause(errTransitionInvalidDate, err)
+		}
+		trnDate = time.Unix(secs, 0).UTC()
+	} else {
+		// While AWS documentation mentions that the date specified
+		// must be present in ISO 8601 format, in reality they allow
+		// users to provide RFC 3339 compliant dates. Some clients
+		// send an ISO 8601 date alone, taken as midnight UTC.
+		var err error
+		trnDate, err = time.Parse(time.RFC3339, dateStr)
+		if err != nil {
+			var dateErr error
+			if trnDate, dateErr = time.Parse(transitionDateOnlyLayout, dateStr); dateErr != nil {
+				return TransitionDate{}, withCause(errTransitionInvalidDate, err)
+			}
+			layout = transitionDateOnlyLayout
+		}
+	}
+	trnDate, err := checkTransitionMidnight(trnDate)
+	if err != nil {
+		return TransitionDate{}, err
+	}
+	return TransitionDate{Time: trnDate, layout: layout}, nil
+}
+
+// format returns Date in the layout it was given in,
+// RFC 3339 by default.
+func (tDate TransitionDate) format() string {
+	if tDate.layout != "" {
+		return tDate.Format(tDate.layout)
+	}
+	return tDate.Format(time.RFC3339)
+}
+
+// AllowNonMidnightTransitionDates relaxes the requirement of Date in
+// Transition being at midnight GMT, such dates are truncated down to
+// midnight GMT of the same day instead of being rejected. Some S3
+// compatible backends accept non-midnight dates, configurations
+// imported from them fail to parse unless this is set.
+var AllowNonMidnightTransitionDates = false
+
+// checkTransitionMidnight allows only date timestamp specifying
+// midnight GMT, unless AllowNonMidnightTransitionDates is set.
+func checkTransitionMidnight(date time.Time) (time.Time, error) {
+	if isMidnightGMT(date) {
+		return date.UTC(), nil
+	}
+	if !AllowNonMidnightTransitionDates {
+		return time.Time{}, errTransitionDateNotMidnight
+	}
+	y, m, d := date.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC), nil
+}
+
+// isMidnightGMT returns true if t is exactly midnight GMT, which is
+// the only time of day allowed for Date in Transition and Expiration
+func isMidnightGMT(t time.Time) bool {
+	hr, min, sec := t.Clock()
+	nsec := t.Nanosecond()
+	// Compare offsets rather than locations, a +00:00 offset parses
+	// to time.Local or to an unnamed zone depending on the server.
+	_, offset := t.Zone()
+	return hr == 0 && min == 0 && sec == 0 && nsec == 0 && offset == 0
 }
 
 // UnmarshalXML parses date from Transition and validates date format
@@ -41,22 +216,11 @@ func (tDate *TransitionDate) UnmarshalXML(d *xml.Decoder, startElement xml.Start
 	if err != nil {
 		return err
 	}
-	// While AWS documentation mentions that the date specified
-	// must be present in ISO 8601 format, in reality they allow
-	// users to provide RFC 3339 compliant dates.
-	trnDate, err := time.Parse(time.RFC3339, dateStr)
+	trnDate, err := parseTransitionDate(dateStr)
 	if err != nil {
-		return errTransitionInvalidDate
-	}
-	// Allow only date timestamp specifying midnight GMT
-	hr, min, sec := trnDate.Clock()
-	nsec := trnDate.Nanosecond()
-	loc := trnDate.Location()
-	if !(hr == 0 && min == 0 && sec == 0 && nsec == 0 && loc.String() == time.UTC.String()) {
-		return errTransitionDateNotMidnight
+		return err
 	}
-
-	*tDate = TransitionDate{trnDate}
+	*tDate = trnDate
 	return nil
 }
 
@@ -66,24 +230,118 @@ func (tDate TransitionDate) MarshalXML(e *xml.Encoder, startElement xml.StartEle
 	if tDate.Time.IsZero() {
 		return nil
 	}
-	return e.EncodeElement(tDate.Format(time.RFC3339), startElement)
+	return e.EncodeElement(tDate.format(), startElement)
+}
+
+// String returns the date part of the transition date, or an empty
+// string if it is not set
+func (tDate TransitionDate) String() string {
+	if tDate.Time.IsZero() {
+		return ""
+	}
+	return tDate.Format("2006-01-02")
+}
+
+// UnmarshalJSON parses date from Transition and validates date format
+func (tDate *TransitionDate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var dateStr string
+	if err := json.Unmarshal(data, &dateStr); err != nil {
+		return err
+	}
+	trnDate, err := parseTransitionDate(dateStr)
+	if err != nil {
+		return err
+	}
+	*tDate = trnDate
+	return nil
+}
+
+// MarshalJSON encodes transition date in the same format as MarshalXML
+func (tDate TransitionDate) MarshalJSON() ([]byte, error) {
+	if tDate.Time.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(tDate.format())
 }
 
 // TransitionDays is a type alias to unmarshal Days in Transition
 type TransitionDays int
 
+// transitionDaysUnits maps the suffixes accepted in Days to the
+// number of days each unit stands for.
+var transitionDaysUnits = []struct {
+	suffix string
+	days   int
+}{
+	{"d", 1},
+	{"w", 7},
+	{"mo", 30},
+	{"y", 365},
+}
+
+// isIndefiniteDays returns true if s is the "Indefinite" literal some
+// legacy exporters write for Days to mean no action, it is treated
+// as if Days was not set.
+func isIndefiniteDays(s string) bool {
+	return strings.EqualFold(strings.TrimSpace(s), "Indefinite")
+}
+
+// MaxTransitionDays is the largest Days accepted by Transition.Validate,
+// about 100 years, beyond which transition times would overflow. Larger
+// Days are still parsed, so that stored configurations load, and are
+// evaluated as MaxTransitionDays.
+const MaxTransitionDays = 36500
+
+// parseTransitionDays parses a number of days, either as a bare
+// integer or as an integer followed by one of the d (days), w (weeks),
+// mo (30-day months) or y (365-day years) suffixes.
+func parseTransitionDays(s string) (TransitionDays, error) {
+	// As for Date, whitespace around the number is ignored.
+	s = strings.TrimSpace(s)
+	if isIndefiniteDays(s) {
+		return 0, nil
+	}
+	multiplier := 1
+	for _, unit := range transitionDaysUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSuffix(s, unit.suffix)
+			multiplier = unit.days
+			break
+		}
+	}
+	// Some exporters emit a leading '+' or zero padding, e.g. "+30" or
+	// "030", any other sign or non digit character is rejected.
+	s = strings.TrimPrefix(s, "+")
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return 0, errTransitionInvalidDays
+	}
+	numDays, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errTransitionInvalidDays
+	}
+	if numDays > int(^uint(0)>>1)/multiplier {
+		// Too large to be represented once the unit is applied.
+		return 0, errTransitionInvalidDays
+	}
+	return TransitionDays(numDays * multiplier), nil
+}
+
 // UnmarshalXML parses number of days from Transition and validates if
 // >= 0
 func (tDays *TransitionDays) UnmarshalXML(d *xml.Decoder, startElement xml.StartElement) error {
-	var numDays int
-	err := d.DecodeElement(&numDays, &startElement)
+	var daysStr string
+	err := d.DecodeElement(&daysStr, &startElement)
 	if err != nil {
 		return err
 	}
-	if numDays < 0 {
-		return errTransitionInvalidDays
+	numDays, err := parseTransitionDays(daysStr)
+	if err != nil {
+		return err
 	}
-	*tDays = TransitionDays(numDays)
+	*tDays = numDays
 	return nil
 }
 
@@ -96,6 +354,36 @@ func (tDays TransitionDays) MarshalXML(e *xml.Encoder, startElement xml.StartEle
 	return e.EncodeElement(int(tDays), startElement)
 }
 
+// String returns the number of days in decimal
+func (tDays TransitionDays) String() string {
+	return strconv.Itoa(int(tDays))
+}
+
+// UnmarshalJSON parses number of days from Transition, which may be
+// given as a JSON number or as a string accepted by UnmarshalXML
+func (tDays *TransitionDays) UnmarshalJSON(data []byte) error {
+	daysStr := string(data)
+	if daysStr == "null" {
+		return nil
+	}
+	if strings.HasPrefix(daysStr, `"`) {
+		if err := json.Unmarshal(data, &daysStr); err != nil {
+			return err
+		}
+	}
+	numDays, err := parseTransitionDays(daysStr)
+	if err != nil {
+		return err
+	}
+	*tDays = numDays
+	return nil
+}
+
+// MarshalJSON encodes number of days as a JSON number
+func (tDays TransitionDays) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int(tDays))
+}
+
 // Transition - transition actions for a rule in lifecycle configuration.
 type Transition struct {
 	XMLName      xml.Name       `xml:"Transition"`
@@ -103,7 +391,10 @@ type Transition struct {
 	Date         TransitionDate `xml:"Date,omitempty"`
 	StorageClass string         `xml:"StorageClass,omitempty"`
 
-	set bool
+	// daysSet is true if Days was provided, which tells an
+	// explicit 0 (transition immediately) apart from no Days.
+	daysSet bool
+	set     bool
 }
 
 // MarshalXML encodes transition field into an XML form.
@@ -111,23 +402,228 @@ func (t Transition) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
 	if !t.set {
 		return nil
 	}
-	type transitionWrapper Transition
-	return enc.EncodeElement(transitionWrapper(t), start)
+	type transitionWrapper struct {
+		Days         *int           `xml:"Days,omitempty"`
+		Date         TransitionDate `xml:"Date,omitempty"`
+		StorageClass string         `xml:"StorageClass,omitempty"`
+	}
+	trw := transitionWrapper{Date: t.Date, StorageClass: t.StorageClass}
+	if !t.IsDaysNull() {
+		days := int(t.Days)
+		trw.Days = &days
+	}
+	return enc.EncodeElement(trw, start)
+}
+
+// storageClassNormalization holds the setting of SetNormalizeStorageClass.
+var storageClassNormalization atomic.Value
+
+// SetNormalizeStorageClass sets whether StorageClass in Transition is
+// upper-cased when unmarshaled, as expected by S3, so that hand edited
+// configurations compare consistently. Normalization is enabled by
+// default, which changes the storage classes of stored configurations,
+// e.g. a rule PUT with "glacier" is returned with "GLACIER". Disable it
+// to keep StorageClass exactly as provided.
+func SetNormalizeStorageClass(normalize bool) {
+	storageClassNormalization.Store(normalize)
+}
+
+func normalizeStorageClass(storageClass string) string {
+	if normalize, ok := storageClassNormalization.Load().(bool); ok && !normalize {
+		return storageClass
+	}
+	return strings.ToUpper(storageClass)
+}
+
+// transitionDaysXML decodes Days in Transition and records
+// whether it was provided, or given as "Indefinite".
+type transitionDaysXML struct {
+	days       TransitionDays
+	set        bool
+	indefinite bool
+}
+
+// UnmarshalXML parses Days like TransitionDays, "Indefinite"
+// is not considered as provided.
+func (tdx *transitionDaysXML) UnmarshalXML(d *xml.Decoder, startElement xml.StartElement) error {
+	var daysStr string
+	if err := d.DecodeElement(&daysStr, &startElement); err != nil {
+		return err
+	}
+	if isIndefiniteDays(daysStr) {
+		*tdx = transitionDaysXML{indefinite: true}
+		return nil
+	}
+	numDays, err := parseTransitionDays(daysStr)
+	if err != nil {
+		return err
+	}
+	*tdx = transitionDaysXML{days: numDays, set: true}
+	return nil
 }
 
-// UnmarshalXML decodes transition field from the XML form.
+// UnmarshalXML decodes transition field from the XML form. A Transition
+// with "Indefinite" Days and no Date is left unset, the object is never
+// transitioned.
 func (t *Transition) UnmarshalXML(d *xml.Decoder, startElement xml.StartElement) error {
-	type transitionWrapper Transition
+	type transitionWrapper struct {
+		XMLName      xml.Name          `xml:"Transition"`
+		Days         transitionDaysXML `xml:"Days"`
+		Date         TransitionDate    `xml:"Date"`
+		StorageClass string            `xml:"StorageClass"`
+	}
 	var trw transitionWrapper
 	err := d.DecodeElement(&trw, &startElement)
 	if err != nil {
 		return err
 	}
-	*t = Transition(trw)
-	t.set = true
+	if trw.Days.indefinite && trw.Date.IsZero() {
+		*t = Transition{}
+		return nil
+	}
+	*t = Transition{
+		XMLName:      trw.XMLName,
+		Days:         trw.Days.days,
+		Date:         trw.Date,
+		StorageClass: normalizeStorageClass(trw.StorageClass),
+		daysSet:      trw.Days.set,
+		set:          true,
+	}
 	return nil
 }
 
+// transitionJSON is the JSON form of Transition, zero fields are
+// omitted the same way they are left out of the XML form.
+type transitionJSON struct {
+	Days         *TransitionDays `json:"Days,omitempty"`
+	Date         *TransitionDate `json:"Date,omitempty"`
+	StorageClass string          `json:"StorageClass,omitempty"`
+}
+
+// MarshalJSON encodes transition field into a JSON form.
+func (t Transition) MarshalJSON() ([]byte, error) {
+	if !t.set {
+		return []byte("null"), nil
+	}
+	trj := transitionJSON{
+		StorageClass: t.StorageClass,
+	}
+	if !t.IsDaysNull() {
+		days := t.Days
+		trj.Days = &days
+	}
+	if !t.IsDateNull() {
+		date := t.Date
+		trj.Date = &date
+	}
+	return json.Marshal(trj)
+}
+
+// UnmarshalJSON decodes transition field from the JSON form.
+func (t *Transition) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var trj transitionJSON
+	if err := json.Unmarshal(data, &trj); err != nil {
+		return err
+	}
+	*t = Transition{
+		StorageClass: normalizeStorageClass(trj.StorageClass),
+		set:          true,
+	}
+	if trj.Days != nil {
+		var raw struct{ Days string }
+		// Days given as the "Indefinite" string is not considered as
+		// provided, without a Date the transition is left unset.
+		if json.Unmarshal(data, &raw) != nil || !isIndefiniteDays(raw.Days) {
+			t.Days, t.daysSet = *trj.Days, true
+		} else if trj.Date == nil {
+			*t = Transition{}
+			return nil
+		}
+	}
+	if trj.Date != nil {
+		t.Date = *trj.Date
+	}
+	return nil
+}
+
+// NewTransitionDays returns a Transition to storageClass once an
+// object is days old, 0 transitions objects immediately.
+func NewTransitionDays(days int, storageClass string) (Transition, error) {
+	if days < 0 {
+		return Transition{}, errTransitionInvalidDays
+	}
+	t := Transition{Days: TransitionDays(days), StorageClass: storageClass, daysSet: true, set: true}
+	if err := t.Validate(); err != nil {
+		return Transition{}, err
+	}
+	return t, nil
+}
+
+// NewTransitionDate returns a Transition to storageClass on date,
+// which must be at midnight GMT, see AllowNonMidnightTransitionDates.
+func NewTransitionDate(date time.Time, storageClass string) (Transition, error) {
+	if date.IsZero() {
+		return Transition{}, errTransitionInvalidDate
+	}
+	date, err := checkTransitionMidnight(date)
+	if err != nil {
+		return Transition{}, err
+	}
+	t := Transition{Date: TransitionDate{Time: date}, StorageClass: storageClass, set: true}
+	if err := t.Validate(); err != nil {
+		return Transition{}, err
+	}
+	return t, nil
+}
+
+// WithDays returns a copy of the transition due once an object is days
+// old, replacing any Date. days must be between 0 and MaxTransitionDays.
+// The original transition is left unchanged.
+func (t Transition) WithDays(days int) (Transition, error) {
+	if days < 0 {
+		return Transition{}, errTransitionInvalidDays
+	}
+	if days > MaxTransitionDays {
+		return Transition{}, errTransitionDaysTooLarge
+	}
+	t.Days, t.daysSet = TransitionDays(days), true
+	t.Date = TransitionDate{}
+	t.set = true
+	return t, nil
+}
+
+// WithStorageClass returns a copy of the transition to storageClass,
+// which must not be empty unless allowed, see SetSentinelStorageClasses.
+// The original transition is left unchanged.
+func (t Transition) WithStorageClass(storageClass string) (Transition, error) {
+	if storageClass == "" && !isSentinelStorageClass(storageClass) {
+		return Transition{}, errXMLNotWellFormed
+	}
+	t.StorageClass = storageClass
+	t.set = true
+	return t, nil
+}
+
+// WithDate returns a copy of the transition due on date, replacing any
+// Days. date must be at midnight GMT, see AllowNonMidnightTransitionDates.
+// The original transition is left unchanged.
+func (t Transition) WithDate(date time.Time) (Transition, error) {
+	if date.IsZero() {
+		return Transition{}, errTransitionInvalidDate
+	}
+	date, err := checkTransitionMidnight(date)
+	if err != nil {
+		return Transition{}, err
+	}
+	t.Date = TransitionDate{Time: date}
+	t.Days, t.daysSet = 0, false
+	t.set = true
+	return t, nil
+}
+
 // Validate - validates the "Expiration" element
 func (t Transition) Validate() error {
 	if !t.set {
@@ -142,15 +638,119 @@ func (t Transition) Validate() error {
 	if !t.IsDaysNull() && !t.IsDateNull() {
 		return errTransitionInvalid
 	}
-	if t.StorageClass == "" {
-		return errXMLNotWellFormed
+	if t.Days < 0 {
+		return errTransitionInvalidDays
+	}
+	if t.Days > MaxTransitionDays {
+		return errTransitionDaysTooLarge
+	}
+	if t.StorageClass == "" && !isSentinelStorageClass(t.StorageClass) {
+		return withElement("StorageClass", errXMLNotWellFormed)
+	}
+	return nil
+}
+
+// defaultSentinelStorageClasses are the storage classes with a special
+// meaning, see SetSentinelStorageClasses.
+var defaultSentinelStorageClasses = []string{"", "DELETE"}
+
+// sentinelStorageClasses holds the setting of SetSentinelStorageClasses.
+var sentinelStorageClasses atomic.Value
+
+// sentinelStorageClassesSetting is the setting of SetSentinelStorageClasses.
+type sentinelStorageClassesSetting struct {
+	allow   bool
+	classes []string
+}
+
+// SetSentinelStorageClasses sets whether Transition can take any of the
+// classes as StorageClass, including an empty one, for backends where
+// such a class means removing the object on transition. Passing nil
+// classes uses the default ones, "" and "DELETE". They are not allowed
+// by default.
+func SetSentinelStorageClasses(allow bool, classes []string) {
+	if classes == nil {
+		classes = defaultSentinelStorageClasses
+	}
+	sentinelStorageClasses.Store(sentinelStorageClassesSetting{
+		allow:   allow,
+		classes: append([]string(nil), classes...),
+	})
+}
+
+// isSentinelStorageClass returns true if storageClass is one of the
+// sentinel storage classes and they are allowed.
+func isSentinelStorageClass(storageClass string) bool {
+	setting, ok := sentinelStorageClasses.Load().(sentinelStorageClassesSetting)
+	if !ok || !setting.allow {
+		return false
+	}
+	for _, sc := range setting.classes {
+		if sc == storageClass {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateWithClasses - validates the "Transition" element like Validate,
+// additionally requiring StorageClass to be one of the allowed classes.
+func (t Transition) ValidateWithClasses(allowed []string) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	if !t.set || isSentinelStorageClass(t.StorageClass) {
+		return nil
+	}
+	for _, sc := range allowed {
+		if t.StorageClass == sc {
+			return nil
+		}
+	}
+	return Errorf("%w: %q is not one of [%s]", errTransitionInvalidStorageClass, t.StorageClass, strings.Join(allowed, ", "))
+}
+
+// ValidateNotBefore returns an error if the transition Date is earlier
+// than ref, which usually indicates a mistyped year. Days based
+// transitions are not checked.
+func (t Transition) ValidateNotBefore(ref time.Time) error {
+	if !t.set || t.IsDateNull() {
+		return nil
+	}
+	if t.Date.Before(ref) {
+		return errTransitionDateInPast
 	}
 	return nil
 }
 
-// IsDaysNull returns true if days field is null
+// TransitionKind tells how a Transition is scheduled, see Transition.Kind.
+type TransitionKind int
+
+const (
+	// TransitionKindNone means neither Days nor Date is set
+	TransitionKindNone TransitionKind = iota
+	// TransitionKindDays means the transition is due Days after the object modification
+	TransitionKindDays
+	// TransitionKindDate means the transition is due on Date
+	TransitionKindDate
+)
+
+// Kind returns whether the transition uses Days or Date, Date wins
+// for an invalid transition having both.
+func (t Transition) Kind() TransitionKind {
+	switch {
+	case !t.IsDateNull():
+		return TransitionKindDate
+	case !t.IsDaysNull():
+		return TransitionKindDays
+	}
+	return TransitionKindNone
+}
+
+// IsDaysNull returns true if days field is null, i.e. Days was not
+// provided. An explicit 0, meaning an immediate transition, is not null.
 func (t Transition) IsDaysNull() bool {
-	return t.Days == TransitionDays(0)
+	return t.Days == TransitionDays(0) && !t.daysSet
 }
 
 // IsDateNull returns true if date field is null
@@ -162,3 +762,90 @@ func (t Transition) IsDateNull() bool {
 func (t Transition) IsNull() bool {
 	return t.IsDaysNull() && t.IsDateNull()
 }
+
+// ShouldTransition returns true along with the target storage class if
+// an object last modified at modTime is due for transition at now. Days
+// are counted from the midnight following modTime, see ExpectedExpiryTime.
+func (t Transition) ShouldTransition(modTime, now time.Time) (bool, string) {
+	if t.IsNull() {
+		return false, ""
+	}
+	if !now.Before(t.transitionTime(modTime)) {
+		return true, t.StorageClass
+	}
+	return false, ""
+}
+
+// NextActionTime returns the instant at which an object last modified at
+// modTime is due for this transition, either the transition Date or the
+// midnight after modTime plus Days. It returns false if the transition
+// is unset.
+func (t Transition) NextActionTime(modTime time.Time) (time.Time, bool) {
+	if t.IsNull() {
+		return time.Time{}, false
+	}
+	return t.transitionTime(modTime), true
+}
+
+// ResolveToDate returns a copy of the transition pinned to the Date it
+// is due for an object last modified at modTime, i.e. the midnight UTC
+// following modTime plus Days, with Days unset. Transitions without
+// Days are returned unchanged.
+func (t Transition) ResolveToDate(modTime time.Time) Transition {
+	if t.IsDaysNull() {
+		return t
+	}
+	resolved := t
+	resolved.Date = TransitionDate{Time: t.transitionTime(modTime)}
+	resolved.Days = 0
+	resolved.daysSet = false
+	return resolved
+}
+
+// transitionTime returns the time at which an object last modified
+// at modTime is due for this transition.
+func (t Transition) transitionTime(modTime time.Time) time.Time {
+	if !t.IsDateNull() {
+		return t.Date.Time
+	}
+	return ExpectedExpiryTime(modTime, t.days())
+}
+
+// days returns Days capped at MaxTransitionDays, so that times computed
+// from it never overflow even if the transition was not validated.
+func (t Transition) days() int {
+	if t.Days > MaxTransitionDays {
+		return MaxTransitionDays
+	}
+	return int(t.Days)
+}
+
+// Clone returns an independent copy of the Transition. All its fields,
+// including the time in Date, are values so a plain copy is enough.
+func (t Transition) Clone() Transition {
+	return t
+}
+
+// String returns a human readable form of the Transition, e.g.
+// Transition(Days=30, StorageClass=GLACIER)
+func (t Transition) String() string {
+	switch {
+	case !t.set:
+		return "Transition(NoneSet)"
+	case !t.IsDateNull():
+		return "Transition(Date=" + t.Date.String() + ", StorageClass=" + t.StorageClass + ")"
+	default:
+		return "Transition(Days=" + t.Days.String() + ", StorageClass=" + t.StorageClass + ")"
+	}
+}
+
+// Equals returns true if both transitions are unset, or if both are set
+// with the same Days, Date and StorageClass.
+func (t Transition) Equals(other Transition) bool {
+	if !t.set || !other.set {
+		return t.set == other.set
+	}
+	return t.Days == other.Days && t.IsDaysNull() == other.IsDaysNull() &&
+		t.Date.Equal(other.Date.Time) &&
+		t.StorageClass == other.StorageClass
+}
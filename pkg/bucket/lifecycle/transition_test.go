@@ -0,0 +1,1303 @@
+/*
+ * MinIO Cloud Storage, (C) 2021 MinIO, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lifecycle
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestTransitionDaysSuffix checks if Days in Transition can be
+// expressed with a unit suffix and is marshaled back as a bare integer
+func TestTransitionDaysSuffix(t *testing.T) {
+	testCases := []struct {
+		inputXML     string
+		expectedDays TransitionDays
+		expectedXML  string
+		expectedErr  error
+	}{
+		{
+			inputXML:     `<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedDays: 30,
+			expectedXML:  `<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+		},
+		{
+			inputXML:     `<Transition><Days>90d</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedDays: 90,
+			expectedXML:  `<Transition><Days>90</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+		},
+		{
+			inputXML:     `<Transition><Days>12w</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedDays: 84,
+			expectedXML:  `<Transition><Days>84</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+		},
+		{
+			inputXML:     `<Transition><Days>6mo</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedDays: 180,
+			expectedXML:  `<Transition><Days>180</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+		},
+		{
+			inputXML:     `<Transition><Days>2y</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedDays: 730,
+			expectedXML:  `<Transition><Days>730</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+		},
+		{ // Leading plus sign
+			inputXML:     `<Transition><Days>+30</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedDays: 30,
+			expectedXML:  `<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+		},
+		{ // Zero padded
+			inputXML:     `<Transition><Days>030</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedDays: 30,
+			expectedXML:  `<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+		},
+		{
+			inputXML:     `<Transition><Days>+002w</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedDays: 14,
+			expectedXML:  `<Transition><Days>14</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+		},
+		{ // Negative days
+			inputXML:    `<Transition><Days>-5</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionInvalidDays,
+		},
+		{
+			inputXML:    `<Transition><Days>-5d</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionInvalidDays,
+		},
+		{
+			inputXML:    `<Transition><Days>-0</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionInvalidDays,
+		},
+		{ // A single sign only
+			inputXML:    `<Transition><Days>++30</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionInvalidDays,
+		},
+		{
+			inputXML:    `<Transition><Days>+</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionInvalidDays,
+		},
+		{ // Combined units are ambiguous
+			inputXML:    `<Transition><Days>1w2d</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionInvalidDays,
+		},
+		{ // Unknown unit
+			inputXML:    `<Transition><Days>3h</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionInvalidDays,
+		},
+		{ // Suffix without a number
+			inputXML:    `<Transition><Days>mo</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionInvalidDays,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var transition Transition
+			err := xml.Unmarshal([]byte(tc.inputXML), &transition)
+			if err != tc.expectedErr {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if tc.expectedErr != nil {
+				return
+			}
+			if transition.Days != tc.expectedDays {
+				t.Fatalf("%d: Expected %d days but got %d", i+1, tc.expectedDays, transition.Days)
+			}
+			b, err := xml.Marshal(transition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.expectedXML {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedXML, string(b))
+			}
+		})
+	}
+}
+
+func TestTransitionShouldTransition(t *testing.T) {
+	modTime := time.Date(2021, time.January, 10, 13, 42, 50, 0, time.UTC)
+	transitionDate := time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC)
+	// 30 days counted from the midnight following modTime
+	daysThreshold := time.Date(2021, time.February, 10, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		transition           Transition
+		now                  time.Time
+		expectedDue          bool
+		expectedStorageClass string
+	}{
+		{ // Unset transition is never due
+			transition: Transition{},
+			now:        daysThreshold.Add(time.Hour),
+		},
+		{ // Days based transition, before threshold
+			transition: Transition{Days: 30, StorageClass: "GLACIER", set: true},
+			now:        daysThreshold.Add(-time.Second),
+		},
+		{ // Days based transition, exactly at threshold
+			transition:           Transition{Days: 30, StorageClass: "GLACIER", set: true},
+			now:                  daysThreshold,
+			expectedDue:          true,
+			expectedStorageClass: "GLACIER",
+		},
+		{ // Days based transition, after threshold
+			transition:           Transition{Days: 30, StorageClass: "GLACIER", set: true},
+			now:                  daysThreshold.Add(48 * time.Hour),
+			expectedDue:          true,
+			expectedStorageClass: "GLACIER",
+		},
+		{ // Date based transition, before date
+			transition: Transition{Date: TransitionDate{Time: transitionDate}, StorageClass: "GLACIER", set: true},
+			now:        transitionDate.Add(-time.Second),
+		},
+		{ // Date based transition, exactly at date
+			transition:           Transition{Date: TransitionDate{Time: transitionDate}, StorageClass: "GLACIER", set: true},
+			now:                  transitionDate,
+			expectedDue:          true,
+			expectedStorageClass: "GLACIER",
+		},
+		{ // Date based transition ignores modTime
+			transition:           Transition{Date: TransitionDate{Time: transitionDate}, StorageClass: "GLACIER", set: true},
+			now:                  transitionDate.Add(time.Hour),
+			expectedDue:          true,
+			expectedStorageClass: "GLACIER",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			due, sc := tc.transition.ShouldTransition(modTime, tc.now)
+			if due != tc.expectedDue || sc != tc.expectedStorageClass {
+				t.Fatalf("%d: Expected (%v, %q) but got (%v, %q)", i+1, tc.expectedDue, tc.expectedStorageClass, due, sc)
+			}
+		})
+	}
+}
+
+// TestTransitionJSON checks if a Transition survives a round trip
+// from XML through JSON and back to XML unchanged
+func TestTransitionJSON(t *testing.T) {
+	testCases := []struct {
+		inputXML     string
+		expectedJSON string
+	}{
+		{
+			inputXML:     `<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedJSON: `{"Days":30,"StorageClass":"GLACIER"}`,
+		},
+		{
+			inputXML:     `<Transition><Date>2021-06-01T00:00:00Z</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedJSON: `{"Date":"2021-06-01T00:00:00Z","StorageClass":"GLACIER"}`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var transition Transition
+			if err := xml.Unmarshal([]byte(tc.inputXML), &transition); err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(transition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.expectedJSON {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedJSON, string(b))
+			}
+			var transition1 Transition
+			if err = json.Unmarshal(b, &transition1); err != nil {
+				t.Fatal(err)
+			}
+			b, err = xml.Marshal(transition1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.inputXML {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.inputXML, string(b))
+			}
+		})
+	}
+}
+
+func TestInvalidTransitionJSON(t *testing.T) {
+	testCases := []struct {
+		inputJSON   string
+		expectedErr error
+	}{
+		{
+			inputJSON:   `{"Days":-1,"StorageClass":"GLACIER"}`,
+			expectedErr: errTransitionInvalidDays,
+		},
+		{
+			inputJSON:   `{"Date":"2021-06-01T10:00:00Z","StorageClass":"GLACIER"}`,
+			expectedErr: errTransitionDateNotMidnight,
+		},
+		{
+			inputJSON:   `{"Date":"invalid date","StorageClass":"GLACIER"}`,
+			expectedErr: errTransitionInvalidDate,
+		},
+		{
+			inputJSON:   `{"Days":"12w","StorageClass":"GLACIER"}`,
+			expectedErr: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var transition Transition
+			if err := json.Unmarshal([]byte(tc.inputJSON), &transition); !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestTransitionValidateWithClasses(t *testing.T) {
+	allowed := []string{"STANDARD_IA", "GLACIER", "DEEP_ARCHIVE"}
+	testCases := []struct {
+		transition  Transition
+		expectedErr error
+	}{
+		{ // Allowed storage class
+			transition:  Transition{Days: 30, StorageClass: "GLACIER", set: true},
+			expectedErr: nil,
+		},
+		{ // Unknown storage class
+			transition:  Transition{Days: 30, StorageClass: "COLDLINE", set: true},
+			expectedErr: errTransitionInvalidStorageClass,
+		},
+		{ // Regular validation still applies
+			transition:  Transition{Days: 30, set: true},
+			expectedErr: errXMLNotWellFormed,
+		},
+		{ // Unset transition
+			transition:  Transition{},
+			expectedErr: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			err := tc.transition.ValidateWithClasses(allowed)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			// Default validation doesn't care about the storage class name
+			if tc.expectedErr == errTransitionInvalidStorageClass {
+				if err = tc.transition.Validate(); err != nil {
+					t.Fatalf("%d: Expected no error from Validate but got %v", i+1, err)
+				}
+			}
+		})
+	}
+}
+
+func TestTransitionDateEpoch(t *testing.T) {
+	testCases := []struct {
+		inputXML     string
+		expectedDate time.Time
+		expectedXML  string
+		expectedErr  error
+	}{
+		{ // Epoch seconds at midnight GMT
+			inputXML:     `<Transition><Date>1609459200</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedDate: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expectedXML:  `<Transition><Date>2021-01-01T00:00:00Z</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+		},
+		{ // Epoch seconds one second past midnight GMT
+			inputXML:    `<Transition><Date>1609459201</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionDateNotMidnight,
+		},
+		{ // Too short for epoch seconds, nor an ISO 8601 date
+			inputXML:    `<Transition><Date>20240101</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionInvalidDate,
+		},
+		{ // Out of range epoch seconds
+			inputXML:    `<Transition><Date>99999999999999999999</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionInvalidDate,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var transition Transition
+			err := xml.Unmarshal([]byte(tc.inputXML), &transition)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if tc.expectedErr != nil {
+				return
+			}
+			if !transition.Date.Equal(tc.expectedDate) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedDate, transition.Date)
+			}
+			b, err := xml.Marshal(transition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.expectedXML {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedXML, string(b))
+			}
+		})
+	}
+}
+
+func TestTransitionClone(t *testing.T) {
+	orig := Transition{Date: TransitionDate{Time: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)}, StorageClass: "GLACIER", set: true}
+	clone := orig.Clone()
+	if clone != orig {
+		t.Fatalf("Expected %v to be equal to %v", clone, orig)
+	}
+	clone.Date.Time = clone.Date.AddDate(0, 1, 0)
+	clone.StorageClass = "DEEP_ARCHIVE"
+	if !orig.Date.Equal(time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)) || orig.StorageClass != "GLACIER" || !orig.set {
+		t.Fatalf("Expected original to be unchanged but got %v", orig)
+	}
+}
+
+func TestTransitionString(t *testing.T) {
+	testCases := []struct {
+		transition Transition
+		expected   string
+	}{
+		{
+			transition: Transition{},
+			expected:   "Transition(NoneSet)",
+		},
+		{
+			transition: Transition{Days: 30, StorageClass: "GLACIER", set: true},
+			expected:   "Transition(Days=30, StorageClass=GLACIER)",
+		},
+		{
+			transition: Transition{Date: TransitionDate{Time: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}, StorageClass: "GLACIER", set: true},
+			expected:   "Transition(Date=2024-01-01, StorageClass=GLACIER)",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if got := tc.transition.String(); got != tc.expected {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTransitionEquals(t *testing.T) {
+	date := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		t1, t2   Transition
+		expected bool
+	}{
+		{ // Identical transitions
+			t1:       Transition{Days: 30, StorageClass: "GLACIER", set: true},
+			t2:       Transition{Days: 30, StorageClass: "GLACIER", set: true},
+			expected: true,
+		},
+		{ // Only the storage class differs
+			t1:       Transition{Days: 30, StorageClass: "GLACIER", set: true},
+			t2:       Transition{Days: 30, StorageClass: "DEEP_ARCHIVE", set: true},
+			expected: false,
+		},
+		{ // Only the set flag differs
+			t1:       Transition{Days: 30, StorageClass: "GLACIER", set: true},
+			t2:       Transition{Days: 30, StorageClass: "GLACIER"},
+			expected: false,
+		},
+		{ // Unset transitions with different leftover fields
+			t1:       Transition{Days: 30, StorageClass: "GLACIER"},
+			t2:       Transition{Date: TransitionDate{Time: date}},
+			expected: true,
+		},
+		{ // Same date in different locations
+			t1:       Transition{Date: TransitionDate{Time: date}, StorageClass: "GLACIER", set: true},
+			t2:       Transition{Date: TransitionDate{Time: date.In(time.FixedZone("UTC+1", 3600))}, StorageClass: "GLACIER", set: true},
+			expected: true,
+		},
+		{ // Days differ
+			t1:       Transition{Days: 30, StorageClass: "GLACIER", set: true},
+			t2:       Transition{Days: 60, StorageClass: "GLACIER", set: true},
+			expected: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if got := tc.t1.Equals(tc.t2); got != tc.expected {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected, got)
+			}
+			if got := tc.t2.Equals(tc.t1); got != tc.expected {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTransitionValidateNotBefore(t *testing.T) {
+	ref := time.Date(2024, time.March, 15, 10, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		transition  Transition
+		expectedErr error
+	}{
+		{ // Mistyped year in the past
+			transition:  Transition{Date: TransitionDate{Time: time.Date(2014, time.June, 1, 0, 0, 0, 0, time.UTC)}, StorageClass: "GLACIER", set: true},
+			expectedErr: errTransitionDateInPast,
+		},
+		{ // Future date
+			transition:  Transition{Date: TransitionDate{Time: time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)}, StorageClass: "GLACIER", set: true},
+			expectedErr: nil,
+		},
+		{ // Days based transitions are not checked
+			transition:  Transition{Days: 30, StorageClass: "GLACIER", set: true},
+			expectedErr: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if err := tc.transition.ValidateNotBefore(ref); err != tc.expectedErr {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			// Default validation accepts past dates
+			if err := tc.transition.Validate(); err != nil {
+				t.Fatalf("%d: Expected no error from Validate but got %v", i+1, err)
+			}
+		})
+	}
+}
+
+func TestNewTransition(t *testing.T) {
+	midnight := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		newTransition func() (Transition, error)
+		expectedXML   string
+		expectedErr   error
+	}{
+		{
+			newTransition: func() (Transition, error) { return NewTransitionDays(30, "GLACIER") },
+			expectedXML:   "<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>",
+		},
+		{ // Immediate transition
+			newTransition: func() (Transition, error) { return NewTransitionDays(0, "GLACIER") },
+			expectedXML:   "<Transition><Days>0</Days><StorageClass>GLACIER</StorageClass></Transition>",
+		},
+		{
+			newTransition: func() (Transition, error) { return NewTransitionDays(-1, "GLACIER") },
+			expectedErr:   errTransitionInvalidDays,
+		},
+		{
+			newTransition: func() (Transition, error) { return NewTransitionDays(30, "") },
+			expectedErr:   errXMLNotWellFormed,
+		},
+		{
+			newTransition: func() (Transition, error) { return NewTransitionDate(midnight, "GLACIER") },
+			expectedXML:   "<Transition><Date>2024-06-01T00:00:00Z</Date><StorageClass>GLACIER</StorageClass></Transition>",
+		},
+		{
+			newTransition: func() (Transition, error) { return NewTransitionDate(midnight.Add(time.Hour), "GLACIER") },
+			expectedErr:   errTransitionDateNotMidnight,
+		},
+		{
+			newTransition: func() (Transition, error) { return NewTransitionDate(time.Time{}, "GLACIER") },
+			expectedErr:   errTransitionInvalidDate,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			tr, err := tc.newTransition()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if tc.expectedErr != nil {
+				return
+			}
+			b, err := xml.Marshal(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.expectedXML {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedXML, string(b))
+			}
+		})
+	}
+}
+
+// TestAllowNonMidnightTransitionDates checks if non-midnight dates in
+// Transition are rejected by default and truncated when allowed
+func TestAllowNonMidnightTransitionDates(t *testing.T) {
+	defer func(allow bool) { AllowNonMidnightTransitionDates = allow }(AllowNonMidnightTransitionDates)
+
+	testCases := []struct {
+		inputXML     string
+		allow        bool
+		expectedDate time.Time
+		expectedErr  error
+	}{
+		{
+			inputXML:    "<Transition><Date>2024-06-01T13:45:00Z</Date><StorageClass>GLACIER</StorageClass></Transition>",
+			expectedErr: errTransitionDateNotMidnight,
+		},
+		{
+			inputXML:     "<Transition><Date>2024-06-01T13:45:00Z</Date><StorageClass>GLACIER</StorageClass></Transition>",
+			allow:        true,
+			expectedDate: time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{ // Truncation happens in GMT
+			inputXML:     "<Transition><Date>2024-06-01T23:00:00-05:00</Date><StorageClass>GLACIER</StorageClass></Transition>",
+			allow:        true,
+			expectedDate: time.Date(2024, time.June, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{ // Midnight dates are unaffected
+			inputXML:     "<Transition><Date>2024-06-01T00:00:00Z</Date><StorageClass>GLACIER</StorageClass></Transition>",
+			allow:        true,
+			expectedDate: time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			AllowNonMidnightTransitionDates = tc.allow
+			var tr Transition
+			err := xml.Unmarshal([]byte(tc.inputXML), &tr)
+			if err != tc.expectedErr {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if tc.expectedErr != nil {
+				return
+			}
+			if !tr.Date.Equal(tc.expectedDate) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedDate, tr.Date)
+			}
+			if err = tr.Validate(); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+		})
+	}
+}
+
+// TestNormalizeStorageClass checks if StorageClass in Transition is
+// upper-cased on unmarshal unless normalization is disabled
+func TestNormalizeStorageClass(t *testing.T) {
+	defer SetNormalizeStorageClass(true)
+
+	testCases := []struct {
+		normalize            bool
+		expectedStorageClass string
+		expectedXML          string
+	}{
+		{
+			normalize:            true,
+			expectedStorageClass: "GLACIER",
+			expectedXML:          "<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition>",
+		},
+		{
+			normalize:            false,
+			expectedStorageClass: "Glacier",
+			expectedXML:          "<Transition><Days>30</Days><StorageClass>Glacier</StorageClass></Transition>",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			SetNormalizeStorageClass(tc.normalize)
+			var tr Transition
+			if err := xml.Unmarshal([]byte("<Transition><Days>30</Days><StorageClass>Glacier</StorageClass></Transition>"), &tr); err != nil {
+				t.Fatal(err)
+			}
+			if tr.StorageClass != tc.expectedStorageClass {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedStorageClass, tr.StorageClass)
+			}
+			b, err := xml.Marshal(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.expectedXML {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedXML, string(b))
+			}
+			var trj Transition
+			if err = json.Unmarshal([]byte(`{"Days":30,"StorageClass":"Glacier"}`), &trj); err != nil {
+				t.Fatal(err)
+			}
+			if trj.StorageClass != tc.expectedStorageClass {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedStorageClass, trj.StorageClass)
+			}
+		})
+	}
+}
+
+// TestTransitionDateParseCause checks if the reason a Date failed to
+// parse is exposed while the error message stays the same
+func TestTransitionDateParseCause(t *testing.T) {
+	var tr Transition
+	err := xml.Unmarshal([]byte("<Transition><Date>2021-02-30T00:00:00Z</Date><StorageClass>GLACIER</StorageClass></Transition>"), &tr)
+	if !errors.Is(err, errTransitionInvalidDate) {
+		t.Fatalf("Expected %v but got %v", errTransitionInvalidDate, err)
+	}
+	if err.Error() != errTransitionInvalidDate.Error() {
+		t.Fatalf("Expected message %q but got %q", errTransitionInvalidDate.Error(), err.Error())
+	}
+	if _, ok := err.(Error); !ok {
+		t.Fatalf("Expected error of type Error but got %T", err)
+	}
+	var parseErr *time.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a *time.ParseError in %v", err)
+	}
+	if parseErr.Message != ": day out of range" {
+		t.Fatalf("Expected day out of range but got %q", parseErr.Message)
+	}
+
+	err = xml.Unmarshal([]byte("<Transition><Date>99999999999999999999</Date><StorageClass>GLACIER</StorageClass></Transition>"), &tr)
+	var numErr *strconv.NumError
+	if !errors.Is(err, errTransitionInvalidDate) || !errors.As(err, &numErr) {
+		t.Fatalf("Expected a *strconv.NumError in %v", err)
+	}
+}
+
+// TestTransitionExplicitZeroDays checks if an explicit 0 in Days is
+// told apart from Days not being provided
+func TestTransitionExplicitZeroDays(t *testing.T) {
+	testCases := []struct {
+		input        string
+		json         bool
+		expectedNull bool
+		expectedErr  error
+		expectedOut  string
+	}{
+		{
+			input:        "<Transition><Days>0</Days><StorageClass>GLACIER</StorageClass></Transition>",
+			expectedNull: false,
+			expectedOut:  "<Transition><Days>0</Days><StorageClass>GLACIER</StorageClass></Transition>",
+		},
+		{
+			input:        "<Transition><StorageClass>GLACIER</StorageClass></Transition>",
+			expectedNull: true,
+			expectedErr:  errXMLNotWellFormed,
+			expectedOut:  "<Transition><StorageClass>GLACIER</StorageClass></Transition>",
+		},
+		{ // Indefinite Days leave the transition unset
+			input:        "<Transition><Days>Indefinite</Days><StorageClass>GLACIER</StorageClass></Transition>",
+			expectedNull: true,
+			expectedOut:  "",
+		},
+		{ // Explicit 0 together with a Date
+			input:        "<Transition><Days>0</Days><Date>2021-01-01T00:00:00Z</Date><StorageClass>GLACIER</StorageClass></Transition>",
+			expectedNull: false,
+			expectedErr:  errTransitionInvalid,
+			expectedOut:  "<Transition><Days>0</Days><Date>2021-01-01T00:00:00Z</Date><StorageClass>GLACIER</StorageClass></Transition>",
+		},
+		{
+			input:        `{"Days":0,"StorageClass":"GLACIER"}`,
+			json:         true,
+			expectedNull: false,
+			expectedOut:  `{"Days":0,"StorageClass":"GLACIER"}`,
+		},
+		{
+			input:        `{"StorageClass":"GLACIER"}`,
+			json:         true,
+			expectedNull: true,
+			expectedErr:  errXMLNotWellFormed,
+			expectedOut:  `{"StorageClass":"GLACIER"}`,
+		},
+		{
+			input:        `{"Days":"indefinite","StorageClass":"GLACIER"}`,
+			json:         true,
+			expectedNull: true,
+			expectedOut:  `null`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var (
+				tr  Transition
+				err error
+			)
+			if tc.json {
+				err = json.Unmarshal([]byte(tc.input), &tr)
+			} else {
+				err = xml.Unmarshal([]byte(tc.input), &tr)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tr.IsDaysNull() != tc.expectedNull {
+				t.Fatalf("%d: Expected IsDaysNull %v but got %v", i+1, tc.expectedNull, tr.IsDaysNull())
+			}
+			if err = tr.Validate(); err != tc.expectedErr {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			var b []byte
+			if tc.json {
+				b, err = json.Marshal(tr)
+			} else {
+				b, err = xml.Marshal(tr)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.expectedOut {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedOut, string(b))
+			}
+		})
+	}
+
+	// An immediate transition is due on the midnight following the
+	// modification time.
+	tr, err := NewTransitionDays(0, "GLACIER")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2021, time.May, 21, 13, 42, 50, 0, time.UTC)
+	if ok, _ := tr.ShouldTransition(modTime, modTime.Add(time.Hour)); ok {
+		t.Fatal("Expected no transition on the day of the modification")
+	}
+	if ok, sc := tr.ShouldTransition(modTime, time.Date(2021, time.May, 22, 0, 0, 0, 0, time.UTC)); !ok || sc != "GLACIER" {
+		t.Fatal("Expected a transition to GLACIER the next midnight")
+	}
+	if tr.Equals(Transition{StorageClass: "GLACIER", set: true}) {
+		t.Fatal("Expected an explicit 0 to differ from no Days")
+	}
+}
+
+func TestTransitionNextActionTime(t *testing.T) {
+	modTime := time.Date(2020, time.May, 21, 13, 42, 50, 0, time.UTC)
+	date := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		transition   Transition
+		expectedTime time.Time
+		expectedOk   bool
+	}{
+		{ // Days are counted from the midnight following modTime
+			transition:   Transition{Days: 3, StorageClass: "GLACIER", daysSet: true, set: true},
+			expectedTime: time.Date(2020, time.May, 25, 0, 0, 0, 0, time.UTC),
+			expectedOk:   true,
+		},
+		{ // Immediate transition
+			transition:   Transition{StorageClass: "GLACIER", daysSet: true, set: true},
+			expectedTime: time.Date(2020, time.May, 22, 0, 0, 0, 0, time.UTC),
+			expectedOk:   true,
+		},
+		{
+			transition:   Transition{Date: TransitionDate{Time: date}, StorageClass: "GLACIER", set: true},
+			expectedTime: date,
+			expectedOk:   true,
+		},
+		{ // Unset transition
+			transition: Transition{},
+		},
+		{ // No Days nor Date
+			transition: Transition{StorageClass: "GLACIER", set: true},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			got, ok := tc.transition.NextActionTime(modTime)
+			if ok != tc.expectedOk {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedOk, ok)
+			}
+			if !got.Equal(tc.expectedTime) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedTime, got)
+			}
+		})
+	}
+}
+
+func TestTransitionDateOnly(t *testing.T) {
+	testCases := []struct {
+		inputXML     string
+		expectedDate time.Time
+		expectedXML  string
+		expectedErr  error
+	}{
+		{ // ISO 8601 date without a time of day
+			inputXML:     `<Transition><Date>2024-01-01</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedDate: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expectedXML:  `<Transition><Date>2024-01-01</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+		},
+		{ // Invalid day of the month
+			inputXML:    `<Transition><Date>2024-02-30</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionInvalidDate,
+		},
+		{ // Neither RFC 3339 nor a date
+			inputXML:    `<Transition><Date>01/01/2024</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedErr: errTransitionInvalidDate,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var transition Transition
+			err := xml.Unmarshal([]byte(tc.inputXML), &transition)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+			if tc.expectedErr != nil {
+				return
+			}
+			if !transition.Date.Equal(tc.expectedDate) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedDate, transition.Date)
+			}
+			if err = transition.Validate(); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			b, err := xml.Marshal(transition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.expectedXML {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.expectedXML, string(b))
+			}
+		})
+	}
+}
+
+func TestSentinelStorageClasses(t *testing.T) {
+	allowed := []string{"GLACIER"}
+	testCases := []struct {
+		transition  Transition
+		allow       bool
+		expectedErr error
+	}{
+		{ // Empty storage class without the flag
+			transition:  Transition{Days: 30, daysSet: true, set: true},
+			expectedErr: errXMLNotWellFormed,
+		},
+		{ // Empty storage class with the flag
+			transition: Transition{Days: 30, daysSet: true, set: true},
+			allow:      true,
+		},
+		{ // DELETE without the flag is an ordinary, unknown class
+			transition:  Transition{Days: 30, StorageClass: "DELETE", daysSet: true, set: true},
+			expectedErr: errTransitionInvalidStorageClass,
+		},
+		{ // DELETE with the flag
+			transition: Transition{Days: 30, StorageClass: "DELETE", daysSet: true, set: true},
+			allow:      true,
+		},
+		{ // Other classes are still checked with the flag
+			transition:  Transition{Days: 30, StorageClass: "COLDLINE", daysSet: true, set: true},
+			allow:       true,
+			expectedErr: errTransitionInvalidStorageClass,
+		},
+	}
+
+	defer SetSentinelStorageClasses(false, nil)
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			SetSentinelStorageClasses(tc.allow, nil)
+			if err := tc.transition.ValidateWithClasses(allowed); !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedErr, err)
+			}
+		})
+	}
+
+	// A rule with an empty StorageClass is valid under the flag
+	SetSentinelStorageClasses(true, nil)
+	var rule Rule
+	if err := xml.Unmarshal([]byte(`<Rule><ID>remove</ID><Status>Enabled</Status><Filter><Prefix>tmp/</Prefix></Filter><Transition><Days>30</Days><StorageClass></StorageClass></Transition></Rule>`), &rule); err != nil {
+		t.Fatal(err)
+	}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	SetSentinelStorageClasses(false, nil)
+	if err := rule.Validate(); !errors.Is(err, errXMLNotWellFormed) {
+		t.Fatalf("Expected %v but got %v", errXMLNotWellFormed, err)
+	}
+}
+
+func TestTransitionResolveToDate(t *testing.T) {
+	modTime := time.Date(2020, time.May, 21, 13, 42, 50, 0, time.UTC)
+	date := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		transition   Transition
+		expectedDate time.Time
+	}{
+		{
+			transition:   Transition{Days: 30, StorageClass: "GLACIER", daysSet: true, set: true},
+			expectedDate: time.Date(2020, time.June, 21, 0, 0, 0, 0, time.UTC),
+		},
+		{ // Immediate transition
+			transition:   Transition{StorageClass: "GLACIER", daysSet: true, set: true},
+			expectedDate: time.Date(2020, time.May, 22, 0, 0, 0, 0, time.UTC),
+		},
+		{ // Date transitions are left unchanged
+			transition:   Transition{Date: TransitionDate{Time: date}, StorageClass: "GLACIER", set: true},
+			expectedDate: date,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			resolved := tc.transition.ResolveToDate(modTime)
+			if !resolved.Date.Equal(tc.expectedDate) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedDate, resolved.Date)
+			}
+			if !resolved.IsDaysNull() || resolved.Days != 0 {
+				t.Fatalf("%d: Expected Days to be unset but got %d", i+1, resolved.Days)
+			}
+			if err := resolved.Validate(); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			if next, _ := tc.transition.NextActionTime(modTime); !next.Equal(resolved.Date.Time) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, next, resolved.Date)
+			}
+			if resolved.StorageClass != tc.transition.StorageClass {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.transition.StorageClass, resolved.StorageClass)
+			}
+		})
+	}
+
+	// The original transition is not modified
+	tr := Transition{Days: 30, StorageClass: "GLACIER", daysSet: true, set: true}
+	tr.ResolveToDate(modTime)
+	if tr.Days != 30 || !tr.IsDateNull() {
+		t.Fatalf("Expected the transition to be unchanged but got %v", tr)
+	}
+}
+
+func TestTransitionGlacierIR(t *testing.T) {
+	var tr Transition
+	if err := xml.Unmarshal([]byte(`<Transition><Days>30</Days><StorageClass>glacier_ir</StorageClass></Transition>`), &tr); err != nil {
+		t.Fatal(err)
+	}
+	if tr.StorageClass != "GLACIER_IR" {
+		t.Fatalf("Expected GLACIER_IR but got %s", tr.StorageClass)
+	}
+	if err := tr.ValidateWithClasses(StorageClassColdness()); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	ia, _ := storageClassRank("STANDARD_IA")
+	ir, ok := storageClassRank("GLACIER_IR")
+	glacier, _ := storageClassRank("GLACIER")
+	if !ok || ir <= ia || ir >= glacier {
+		t.Fatalf("Expected GLACIER_IR to rank between STANDARD_IA and GLACIER but got %d", ir)
+	}
+}
+
+// TestTransitionWhitespaceAndComments checks that whitespace and
+// comments, e.g. in hand edited configurations, are ignored
+func TestTransitionWhitespaceAndComments(t *testing.T) {
+	date := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		inputXML     string
+		expectedDays TransitionDays
+		expectedDate time.Time
+	}{
+		{
+			inputXML:     `<Transition><Days> 30 </Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedDays: 30,
+		},
+		{
+			inputXML: `<Transition>
+				<!-- archive after a month -->
+				<Days>
+					30
+				</Days>
+				<StorageClass>GLACIER</StorageClass>
+			</Transition>`,
+			expectedDays: 30,
+		},
+		{
+			inputXML:     `<Transition><Days><!-- a month -->30<!-- days --></Days><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedDays: 30,
+		},
+		{
+			inputXML:     `<Transition><Date> 2021-01-01T00:00:00Z </Date><StorageClass>GLACIER</StorageClass></Transition>`,
+			expectedDate: date,
+		},
+		{
+			inputXML: `<Transition>
+				<Date>
+					<!-- new year -->
+					2021-01-01T00:00:00Z
+				</Date>
+				<StorageClass>GLACIER</StorageClass>
+			</Transition>`,
+			expectedDate: date,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var tr Transition
+			if err := xml.Unmarshal([]byte(tc.inputXML), &tr); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			if err := tr.Validate(); err != nil {
+				t.Fatalf("%d: Expected no error but got %v", i+1, err)
+			}
+			if tr.Days != tc.expectedDays {
+				t.Fatalf("%d: Expected %d days but got %d", i+1, tc.expectedDays, tr.Days)
+			}
+			if !tr.Date.Equal(tc.expectedDate) {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedDate, tr.Date)
+			}
+		})
+	}
+}
+
+func TestTransitionDateLayoutRoundTrip(t *testing.T) {
+	testCases := []struct {
+		inputXML  string
+		inputJSON string
+	}{
+		{ // Date only
+			inputXML:  `<Transition><Date>2024-01-01</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+			inputJSON: `{"Date":"2024-01-01","StorageClass":"GLACIER"}`,
+		},
+		{ // RFC 3339
+			inputXML:  `<Transition><Date>2024-01-01T00:00:00Z</Date><StorageClass>GLACIER</StorageClass></Transition>`,
+			inputJSON: `{"Date":"2024-01-01T00:00:00Z","StorageClass":"GLACIER"}`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var tr Transition
+			if err := xml.Unmarshal([]byte(tc.inputXML), &tr); err != nil {
+				t.Fatal(err)
+			}
+			b, err := xml.Marshal(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.inputXML {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.inputXML, string(b))
+			}
+
+			var trj Transition
+			if err = json.Unmarshal([]byte(tc.inputJSON), &trj); err != nil {
+				t.Fatal(err)
+			}
+			if b, err = json.Marshal(trj); err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.inputJSON {
+				t.Fatalf("%d: Expected %s but got %s", i+1, tc.inputJSON, string(b))
+			}
+		})
+	}
+
+	// Dates set programmatically are written in RFC 3339
+	tr, err := NewTransitionDate(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), "GLACIER")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := xml.Marshal(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := testCases[1].inputXML; string(b) != expected {
+		t.Fatalf("Expected %s but got %s", expected, string(b))
+	}
+
+	// The layout doesn't make dates different
+	var dateOnly Transition
+	if err = xml.Unmarshal([]byte(testCases[0].inputXML), &dateOnly); err != nil {
+		t.Fatal(err)
+	}
+	if !dateOnly.Equals(tr) {
+		t.Fatalf("Expected %v to equal %v", dateOnly, tr)
+	}
+}
+
+func TestTransitionWithSetters(t *testing.T) {
+	date := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	var orig Transition
+	tr, err := orig.WithDays(30)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if tr, err = tr.WithStorageClass("GLACIER"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !orig.IsNull() || orig.set || orig.StorageClass != "" {
+		t.Fatalf("Expected the original transition to be unchanged but got %v", orig)
+	}
+	if err := tr.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if tr.Days != 30 || tr.StorageClass != "GLACIER" || !tr.IsDateNull() {
+		t.Fatalf("Unexpected transition %v", tr)
+	}
+
+	// An explicit 0 means an immediate transition
+	if immediate, err := tr.WithDays(0); err != nil || immediate.IsDaysNull() || immediate.Validate() != nil {
+		t.Fatalf("Expected an immediate transition but got %v", immediate)
+	}
+
+	dated, err := tr.WithDate(date)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if err = dated.Validate(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !dated.IsDaysNull() || !dated.Date.Equal(date) || dated.StorageClass != "GLACIER" {
+		t.Fatalf("Unexpected transition %v", dated)
+	}
+	if tr.Days != 30 || !tr.IsDateNull() {
+		t.Fatalf("Expected the original transition to be unchanged but got %v", tr)
+	}
+
+	// Back to days replaces the date
+	if days, err := dated.WithDays(7); err != nil || !days.IsDateNull() || days.Validate() != nil {
+		t.Fatalf("Expected a days transition but got %v", days)
+	}
+
+	if _, err = tr.WithDate(date.Add(time.Hour)); err != errTransitionDateNotMidnight {
+		t.Fatalf("Expected %v but got %v", errTransitionDateNotMidnight, err)
+	}
+	if _, err = tr.WithDate(time.Time{}); err != errTransitionInvalidDate {
+		t.Fatalf("Expected %v but got %v", errTransitionInvalidDate, err)
+	}
+	if _, err = tr.WithDays(-1); err != errTransitionInvalidDays {
+		t.Fatalf("Expected %v but got %v", errTransitionInvalidDays, err)
+	}
+	if _, err = tr.WithDays(MaxTransitionDays + 1); err != errTransitionDaysTooLarge {
+		t.Fatalf("Expected %v but got %v", errTransitionDaysTooLarge, err)
+	}
+	if _, err = tr.WithStorageClass(""); err != errXMLNotWellFormed {
+		t.Fatalf("Expected %v but got %v", errXMLNotWellFormed, err)
+	}
+	if days, _ := orig.WithDays(30); days.Validate() == nil {
+		t.Fatal("Expected an error for a transition without StorageClass")
+	}
+}
+
+func TestTransitionKind(t *testing.T) {
+	date := TransitionDate{Time: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	testCases := []struct {
+		transition Transition
+		expected   TransitionKind
+	}{
+		{Transition{}, TransitionKindNone},
+		{Transition{StorageClass: "GLACIER", set: true}, TransitionKindNone},
+		{Transition{Days: 30, StorageClass: "GLACIER", daysSet: true, set: true}, TransitionKindDays},
+		{Transition{StorageClass: "GLACIER", daysSet: true, set: true}, TransitionKindDays},
+		{Transition{Date: date, StorageClass: "GLACIER", set: true}, TransitionKindDate},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if got := tc.transition.Kind(); got != tc.expected {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTransitionDaysTooLarge(t *testing.T) {
+	// Too large Days are parsed, so that stored configurations
+	// load, and rejected by Validate
+	testCases := []struct {
+		days                string
+		expectedParseErr    error
+		expectedValidateErr error
+	}{
+		{"36500", nil, nil},
+		{"36501", nil, errTransitionDaysTooLarge},
+		{"100y", nil, nil},
+		{"101y", nil, errTransitionDaysTooLarge},
+		{"9223372036854775807", nil, errTransitionDaysTooLarge},
+		{"9223372036854775807y", errTransitionInvalidDays, nil},
+		{"99999999999999999999", errTransitionInvalidDays, nil},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			var tr Transition
+			err := xml.Unmarshal([]byte(`<Transition><Days>`+tc.days+`</Days><StorageClass>GLACIER</StorageClass></Transition>`), &tr)
+			if err != tc.expectedParseErr {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedParseErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if err = tr.Validate(); err != tc.expectedValidateErr {
+				t.Fatalf("%d: Expected %v but got %v", i+1, tc.expectedValidateErr, err)
+			}
+		})
+	}
+
+	// Too large Days have their transition time
+	// capped rather than overflowing
+	modTime := time.Date(2020, time.May, 21, 13, 42, 50, 0, time.UTC)
+	tr := Transition{Days: TransitionDays(math.MaxInt32), StorageClass: "GLACIER", daysSet: true, set: true}
+	if err := tr.Validate(); err != errTransitionDaysTooLarge {
+		t.Fatalf("Expected %v but got %v", errTransitionDaysTooLarge, err)
+	}
+	next, ok := tr.NextActionTime(modTime)
+	if expected := ExpectedExpiryTime(modTime, MaxTransitionDays); !ok || !next.Equal(expected) {
+		t.Fatalf("Expected %v but got %v", expected, next)
+	}
+	if due, _ := tr.ShouldTransition(modTime, modTime.AddDate(1, 0, 0)); due {
+		t.Fatal("Expected the transition not to be due")
+	}
+}
+
+func TestCompareStorageClasses(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "STANDARD_IA", b: "GLACIER", expected: -1},
+		{a: "DEEP_ARCHIVE", b: "GLACIER", expected: 1},
+		{a: "GLACIER", b: "GLACIER", expected: 0},
+		{a: "GLACIER", b: "WARM-TIER", expected: -1},
+		{a: "WARM-TIER", b: "DEEP_ARCHIVE", expected: 1},
+		{a: "COLD-TIER", b: "WARM-TIER", expected: -1},
+		{a: "WARM-TIER", b: "WARM-TIER", expected: 0},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			if got := CompareStorageClasses(tc.a, tc.b); got != tc.expected {
+				t.Fatalf("%d: Expected %d but got %d", i+1, tc.expected, got)
+			}
+			if got := CompareStorageClasses(tc.b, tc.a); got != -tc.expected {
+				t.Fatalf("%d: Expected %d but got %d", i+1, -tc.expected, got)
+			}
+		})
+	}
+
+	classes := []string{"WARM-TIER", "GLACIER", "COLD-TIER", "STANDARD", "DEEP_ARCHIVE", "STANDARD_IA"}
+	sort.Slice(classes, func(i, j int) bool {
+		return CompareStorageClasses(classes[i], classes[j]) < 0
+	})
+	expected := []string{"STANDARD", "STANDARD_IA", "GLACIER", "DEEP_ARCHIVE", "COLD-TIER", "WARM-TIER"}
+	if !reflect.DeepEqual(classes, expected) {
+		t.Fatalf("Expected %v but got %v", expected, classes)
+	}
+
+	// The ranking can be replaced
+	defer SetStorageClassColdness(nil)
+	SetStorageClassColdness([]string{"HOT", "WARM", "COLD"})
+	if got := CompareStorageClasses("COLD", "WARM"); got != 1 {
+		t.Fatalf("Expected 1 but got %d", got)
+	}
+}